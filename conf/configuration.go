@@ -19,6 +19,7 @@ package conf
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,16 +28,24 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 
+	"github.com/RedHatInsights/insights-content-service/logger"
 	"github.com/RedHatInsights/insights-content-service/server"
+	"github.com/RedHatInsights/insights-content-service/tracing"
 )
 
 const (
 	configFileEnvVariableName = "INSIGHTS_CONTENT_SERVICE_CONFIG_FILE"
+	apiKeysEnvVariableName    = "INSIGHTS_CONTENT_SERVICE_API_KEYS"
+
+	// redactedValue replaces secrets in redacted configuration
+	redactedValue = "[REDACTED]"
 )
 
 // Config has exactly the same structure as *.toml file
 var Config struct {
-	Server server.Configuration `mapstructure:"server" toml:"server"`
+	Server  server.Configuration  `mapstructure:"server" toml:"server"`
+	Logging logger.Configuration  `mapstructure:"logging" toml:"logging"`
+	Tracing tracing.Configuration `mapstructure:"tracing" toml:"tracing"`
 }
 
 // LoadConfiguration loads configuration from defaultConfigFile, file set in configFileEnvVariableName or from env
@@ -88,6 +97,38 @@ func LoadConfiguration(defaultConfigFile string) error {
 	return viper.Unmarshal(&Config)
 }
 
+// GetLoggingConfiguration returns logging configuration
+func GetLoggingConfiguration() logger.Configuration {
+	return Config.Logging
+}
+
+// GetTracingConfiguration returns tracing configuration
+func GetTracingConfiguration() tracing.Configuration {
+	return Config.Tracing
+}
+
+// GetRedactedConfiguration returns copy of the whole configuration with
+// secrets replaced, so it can be shown to operators
+func GetRedactedConfiguration() interface{} {
+	redacted := Config
+
+	cloudWatch := &redacted.Logging.CloudWatch
+	cloudWatch.AWSAccessID = redact(cloudWatch.AWSAccessID)
+	cloudWatch.AWSSecretKey = redact(cloudWatch.AWSSecretKey)
+	cloudWatch.AWSSessionToken = redact(cloudWatch.AWSSessionToken)
+
+	return redacted
+}
+
+// redact replaces non-empty secret, empty value is kept to show that the
+// secret is not configured
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedValue
+}
+
 // GetServerConfiguration returns server configuration
 func GetServerConfiguration() server.Configuration {
 	err := checkIfFileExists(Config.Server.APISpecFile)
@@ -98,6 +139,27 @@ func GetServerConfiguration() server.Configuration {
 	return Config.Server
 }
 
+// GetAPIKeys returns static API keys read from the document stored in
+// apiKeysEnvVariableName or, when it is not set, from the API keys file
+// specified in server configuration
+func GetAPIKeys() ([]server.APIKey, error) {
+	if apiKeys, specified := os.LookupEnv(apiKeysEnvVariableName); specified {
+		return server.ParseAPIKeys(apiKeys)
+	}
+
+	if Config.Server.APIKeysFile == "" {
+		return nil, nil
+	}
+
+	// #nosec G304
+	apiKeys, err := ioutil.ReadFile(Config.Server.APIKeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read API keys file: %s", err)
+	}
+
+	return server.ParseAPIKeys(string(apiKeys))
+}
+
 // checkIfFileExists returns nil if path doesn't exist or isn't a file, otherwise it returns corresponding error
 func checkIfFileExists(path string) error {
 	fileInfo, err := os.Stat(path)
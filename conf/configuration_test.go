@@ -15,3 +15,84 @@ limitations under the License.
 */
 
 package conf_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/conf"
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+func mustSetEnv(t *testing.T, key, val string) {
+	err := os.Setenv(key, val)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustUnsetEnv(t *testing.T, key string) {
+	err := os.Unsetenv(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetAPIKeysFromEnv(t *testing.T) {
+	mustSetEnv(t, "INSIGHTS_CONTENT_SERVICE_API_KEYS", `
+[[api_keys]]
+service = "smart-proxy"
+hash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+`)
+	defer mustUnsetEnv(t, "INSIGHTS_CONTENT_SERVICE_API_KEYS")
+
+	apiKeys, err := conf.GetAPIKeys()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []server.APIKey{{
+		Service: "smart-proxy",
+		Hash:    "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}}, apiKeys)
+}
+
+func TestGetAPIKeysNotConfigured(t *testing.T) {
+	conf.Config.Server.APIKeysFile = ""
+
+	apiKeys, err := conf.GetAPIKeys()
+
+	assert.NoError(t, err)
+	assert.Empty(t, apiKeys)
+}
+
+func TestGetAPIKeysMissingFile(t *testing.T) {
+	conf.Config.Server.APIKeysFile = "non-existing-file.toml"
+	defer func() { conf.Config.Server.APIKeysFile = "" }()
+
+	_, err := conf.GetAPIKeys()
+
+	assert.Error(t, err)
+}
+
+func TestGetRedactedConfiguration(t *testing.T) {
+	conf.Config.Logging.CloudWatch.AWSAccessID = "access-id"
+	conf.Config.Logging.CloudWatch.AWSSecretKey = "secret-key"
+	conf.Config.Logging.CloudWatch.AWSSessionToken = ""
+	defer func() {
+		conf.Config.Logging.CloudWatch.AWSAccessID = ""
+		conf.Config.Logging.CloudWatch.AWSSecretKey = ""
+	}()
+
+	configBytes, err := json.Marshal(conf.GetRedactedConfiguration())
+	assert.NoError(t, err)
+
+	assert.NotContains(t, string(configBytes), "access-id")
+	assert.NotContains(t, string(configBytes), "secret-key")
+	assert.Contains(t, string(configBytes), `"AWSSecretKey":"[REDACTED]"`)
+	assert.Contains(t, string(configBytes), `"AWSSessionToken":""`)
+
+	// the configuration itself is not changed
+	assert.Equal(t, "secret-key", conf.Config.Logging.CloudWatch.AWSSecretKey)
+}
@@ -0,0 +1,179 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package formats contains encoders and decoders of the formats the content
+// can be served in, and negotiation of the format by Accept header
+package formats
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"sigs.k8s.io/yaml"
+)
+
+// Supported formats
+const (
+	JSON        = "json"
+	YAML        = "yaml"
+	Gob         = "gob"
+	MessagePack = "msgpack"
+)
+
+// structTag is used by all formats, so that the field names are the same
+// in every format
+const structTag = "json"
+
+// mediaTypes maps media types to formats, the first media type of each
+// format is used in Content-Type header
+var mediaTypes = []struct {
+	mediaType string
+	format    string
+}{
+	{"application/json", JSON},
+	{"application/yaml", YAML},
+	{"application/x-yaml", YAML},
+	{"text/yaml", YAML},
+	{"application/x-gob", Gob},
+	{"application/msgpack", MessagePack},
+	{"application/x-msgpack", MessagePack},
+}
+
+func init() {
+	// generic values, sparse fieldsets of content for example, are made
+	// of maps and arrays that gob has to know about
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// IsSupported checks whether given format is supported
+func IsSupported(format string) bool {
+	return format == JSON || format == YAML || format == Gob || format == MessagePack
+}
+
+// MediaType returns media type of given format used in Content-Type header
+func MediaType(format string) string {
+	for _, m := range mediaTypes {
+		if m.format == format {
+			return m.mediaType
+		}
+	}
+	return ""
+}
+
+// Negotiate returns the format preferred by caller according to Accept
+// header, JSON is used when the header is empty or allows any media type.
+// False is returned when no supported format is acceptable.
+func Negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return JSON, true
+	}
+
+	best, bestQuality := "", 0.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := params["q"]; found {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		format := formatOfMediaType(mediaType)
+		if format != "" && quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+
+	return best, best != ""
+}
+
+// formatOfMediaType returns format of given media type, wildcards select JSON
+func formatOfMediaType(mediaType string) string {
+	if mediaType == "*/*" || mediaType == "application/*" {
+		return JSON
+	}
+
+	for _, m := range mediaTypes {
+		if m.mediaType == mediaType {
+			return m.format
+		}
+	}
+	return ""
+}
+
+// Encode writes value to writer in given format, JSON is indented when
+// pretty is true. Pretty printing is ignored by the other formats.
+func Encode(format string, writer io.Writer, value interface{}, pretty bool) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(writer)
+		if pretty {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(value)
+	case YAML:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(data)
+		return err
+	case Gob:
+		return gob.NewEncoder(writer).Encode(value)
+	case MessagePack:
+		encoder := msgpack.NewEncoder(writer)
+		encoder.SetCustomStructTag(structTag)
+		return encoder.Encode(value)
+	default:
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
+}
+
+// Decode reads value in given format from reader
+func Decode(format string, reader io.Reader, value interface{}) error {
+	switch format {
+	case JSON:
+		return json.NewDecoder(reader).Decode(value)
+	case YAML:
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		return yaml.Unmarshal(data, value)
+	case Gob:
+		return gob.NewDecoder(reader).Decode(value)
+	case MessagePack:
+		decoder := msgpack.NewDecoder(reader)
+		decoder.SetCustomStructTag(structTag)
+		return decoder.Decode(value)
+	default:
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
+}
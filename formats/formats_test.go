@@ -0,0 +1,115 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package formats_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/formats"
+)
+
+type rule struct {
+	RuleID   string   `json:"rule_id"`
+	Impact   int      `json:"impact"`
+	Tags     []string `json:"tags"`
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+func TestNegotiate(t *testing.T) {
+	for accept, expected := range map[string]string{
+		"":                                      formats.JSON,
+		"*/*":                                   formats.JSON,
+		"application/*":                         formats.JSON,
+		"application/json":                      formats.JSON,
+		"application/yaml":                      formats.YAML,
+		"text/yaml":                             formats.YAML,
+		"application/x-gob":                     formats.Gob,
+		"application/msgpack":                   formats.MessagePack,
+		"application/x-msgpack":                 formats.MessagePack,
+		"text/html, application/yaml;q=0.9":     formats.YAML,
+		"application/json;q=0.5, text/yaml":     formats.YAML,
+		"application/x-gob;q=0.1, */*;q=0.2":    formats.JSON,
+		"application/msgpack, application/json": formats.MessagePack,
+	} {
+		format, found := formats.Negotiate(accept)
+		assert.True(t, found, accept)
+		assert.Equal(t, expected, format, accept)
+	}
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	for _, accept := range []string{"text/html", "application/xml, text/plain", "application/json;q=0"} {
+		_, found := formats.Negotiate(accept)
+		assert.False(t, found, accept)
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	assert.Equal(t, "application/json", formats.MediaType(formats.JSON))
+	assert.Equal(t, "application/yaml", formats.MediaType(formats.YAML))
+	assert.Equal(t, "application/x-gob", formats.MediaType(formats.Gob))
+	assert.Equal(t, "application/msgpack", formats.MediaType(formats.MessagePack))
+	assert.Equal(t, "", formats.MediaType("xml"))
+}
+
+func TestEncodeDecodeStruct(t *testing.T) {
+	value := rule{RuleID: "nodes_kubelet_version_check", Impact: 2, Tags: []string{"openshift", "node"}}
+
+	for _, format := range []string{formats.JSON, formats.YAML, formats.Gob, formats.MessagePack} {
+		var buffer bytes.Buffer
+		assert.NoError(t, formats.Encode(format, &buffer, value, false), format)
+
+		var decoded rule
+		assert.NoError(t, formats.Decode(format, &buffer, &decoded), format)
+		assert.Equal(t, value, decoded, format)
+	}
+}
+
+func TestEncodeUsesJSONFieldNames(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, formats.Encode(formats.YAML, &buffer, rule{RuleID: "r", Impact: 1}, false))
+	assert.Equal(t, "impact: 1\nrule_id: r\ntags: null\n", buffer.String())
+
+	buffer.Reset()
+	assert.NoError(t, formats.Encode(formats.MessagePack, &buffer, rule{RuleID: "r"}, false))
+	var decoded map[string]interface{}
+	assert.NoError(t, formats.Decode(formats.MessagePack, &buffer, &decoded))
+	assert.Contains(t, decoded, "rule_id")
+	assert.NotContains(t, decoded, "disabled")
+}
+
+func TestEncodePrettyJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, formats.Encode(formats.JSON, &buffer, map[string]int{"impact": 2}, true))
+	assert.Equal(t, "{\n  \"impact\": 2\n}\n", buffer.String())
+
+	buffer.Reset()
+	assert.NoError(t, formats.Encode(formats.JSON, &buffer, map[string]int{"impact": 2}, false))
+	assert.Equal(t, "{\"impact\":2}\n", buffer.String())
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.EqualError(t, formats.Encode("xml", &buffer, 1, false), "unsupported format 'xml'")
+
+	var value int
+	assert.EqualError(t, formats.Decode("xml", &buffer, &value), "unsupported format 'xml'")
+	assert.False(t, formats.IsSupported("xml"))
+}
@@ -1,16 +1,31 @@
 module github.com/RedHatInsights/insights-content-service
 
-go 1.13
+go 1.15
 
 require (
 	github.com/BurntSushi/toml v0.3.1
 	github.com/RedHatInsights/insights-operator-utils v0.0.0-20200430065955-b0b675035360
+	github.com/aws/aws-sdk-go v1.30.29
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/droptheplot/abcgo v0.0.0-20171120220436-23529565504c // indirect
 	github.com/gordonklaus/ineffassign v0.0.0-20200309095847-7953dde2c7bf // indirect
+	github.com/gorilla/mux v1.7.4
 	github.com/kisielk/errcheck v1.2.0 // indirect
+	github.com/klauspost/compress v1.11.13
+	github.com/mitchellh/mapstructure v1.1.2
+	github.com/prometheus/client_golang v1.6.0
 	github.com/rs/zerolog v1.18.0
 	github.com/securego/gosec v0.0.0-20200401082031-e946c8c39989 // indirect
 	github.com/spf13/viper v1.6.3
+	github.com/stretchr/testify v1.7.0
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.0.1
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
 	golang.org/x/lint v0.0.0-20200302205851-738671d3881b // indirect
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
+	gopkg.in/natefinch/lumberjack.v2 v2.0.0
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -0,0 +1,234 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+)
+
+const (
+	defaultFlushInterval = time.Second
+	// maxBatchEvents and maxBatchSize keep batches well below limits of
+	// PutLogEvents call
+	maxBatchEvents = 1000
+	maxBatchSize   = 512 * 1024
+	// eventOverhead is number of bytes CloudWatch adds to each event size
+	eventOverhead = 26
+	// maxQueuedBatches limits number of full batches waiting to be sent,
+	// further batches are dropped so logging never blocks on CloudWatch
+	maxQueuedBatches = 16
+)
+
+// CloudWatchWriter sends log messages to CloudWatch log stream. Messages are
+// buffered and sent in batches periodically and when the writer is closed.
+type CloudWatchWriter struct {
+	client        cloudwatchlogsiface.CloudWatchLogsAPI
+	logGroup      string
+	streamName    string
+	mutex         sync.Mutex
+	events        []*cloudwatchlogs.InputLogEvent
+	batchSize     int
+	batches       chan []*cloudwatchlogs.InputLogEvent
+	sequenceToken *string
+	done          chan struct{}
+	finished      sync.WaitGroup
+}
+
+// newCloudWatchClient constructs CloudWatch Logs client from configuration
+func newCloudWatchClient(config CloudWatchConfiguration) (*cloudwatchlogs.CloudWatchLogs, error) {
+	awsConfig := aws.NewConfig().WithRegion(config.AWSRegion)
+
+	// default credential chain (environment, shared credentials file,
+	// instance or pod role) is used when no static keys are configured
+	if config.AWSAccessID != "" || config.AWSSecretKey != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(
+			config.AWSAccessID, config.AWSSecretKey, config.AWSSessionToken,
+		))
+	}
+
+	if config.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(config.Endpoint)
+	}
+
+	cloudWatchSession, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloudwatchlogs.New(cloudWatchSession), nil
+}
+
+// NewCloudWatchWriter constructs writer sending log messages to CloudWatch
+// log stream specified in configuration
+func NewCloudWatchWriter(config CloudWatchConfiguration) (*CloudWatchWriter, error) {
+	client, err := newCloudWatchClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.CreateStreamIfNotExists {
+		_, err := client.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+			LogGroupName:  aws.String(config.LogGroup),
+			LogStreamName: aws.String(config.StreamName),
+		})
+		if awsErr, ok := err.(awserr.Error); err != nil &&
+			!(ok && awsErr.Code() == cloudwatchlogs.ErrCodeResourceAlreadyExistsException) {
+			return nil, err
+		}
+	}
+
+	flushInterval := config.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	writer := &CloudWatchWriter{
+		client:     client,
+		logGroup:   config.LogGroup,
+		streamName: config.StreamName,
+		batches:    make(chan []*cloudwatchlogs.InputLogEvent, maxQueuedBatches),
+		done:       make(chan struct{}),
+	}
+
+	writer.finished.Add(1)
+	go writer.flushPeriodically(flushInterval)
+
+	return writer, nil
+}
+
+// Write buffers one log message, zerolog writes whole messages at once
+func (writer *CloudWatchWriter) Write(message []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.events = append(writer.events, &cloudwatchlogs.InputLogEvent{
+		Message:   aws.String(string(message)),
+		Timestamp: aws.Int64(time.Now().UnixNano() / int64(time.Millisecond)),
+	})
+	writer.batchSize += len(message) + eventOverhead
+
+	if len(writer.events) >= maxBatchEvents || writer.batchSize >= maxBatchSize {
+		// full batch is sent by the background goroutine
+		events := writer.takeEvents()
+		select {
+		case writer.batches <- events:
+		default:
+			fmt.Fprintf(os.Stderr, "CloudWatch queue is full, dropping %d log messages\n", len(events))
+		}
+	}
+
+	return len(message), nil
+}
+
+// Close sends all queued and buffered log messages and stops the writer
+func (writer *CloudWatchWriter) Close() error {
+	close(writer.done)
+	writer.finished.Wait()
+
+	return nil
+}
+
+// takeEvents returns buffered events and starts a new batch, the mutex has
+// to be held by caller
+func (writer *CloudWatchWriter) takeEvents() []*cloudwatchlogs.InputLogEvent {
+	events := writer.events
+	writer.events = nil
+	writer.batchSize = 0
+	return events
+}
+
+// pendingEvents returns events buffered since the last batch was taken
+func (writer *CloudWatchWriter) pendingEvents() []*cloudwatchlogs.InputLogEvent {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	return writer.takeEvents()
+}
+
+// flushPeriodically is the only caller of PutLogEvents, it sends full
+// batches as they are queued and the buffered events once per interval
+// and when the writer is closed
+func (writer *CloudWatchWriter) flushPeriodically(interval time.Duration) {
+	defer writer.finished.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case events := <-writer.batches:
+			writer.flush(events)
+		case <-ticker.C:
+			writer.flush(writer.pendingEvents())
+		case <-writer.done:
+			for {
+				select {
+				case events := <-writer.batches:
+					writer.flush(events)
+				default:
+					writer.flush(writer.pendingEvents())
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush sends the log messages, it is called from the background goroutine
+// only. Errors are reported to standard error output because the logger
+// itself can not be used.
+func (writer *CloudWatchWriter) flush(events []*cloudwatchlogs.InputLogEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	err := writer.putLogEvents(events)
+	if awsErr, ok := err.(*cloudwatchlogs.InvalidSequenceTokenException); ok {
+		// other writer has used the stream in the meantime
+		writer.sequenceToken = awsErr.ExpectedSequenceToken
+		err = writer.putLogEvents(events)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to send log messages to CloudWatch: %v\n", err)
+	}
+}
+
+func (writer *CloudWatchWriter) putLogEvents(events []*cloudwatchlogs.InputLogEvent) error {
+	output, err := writer.client.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{
+		LogEvents:     events,
+		LogGroupName:  aws.String(writer.logGroup),
+		LogStreamName: aws.String(writer.streamName),
+		SequenceToken: writer.sequenceToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.sequenceToken = output.NextSequenceToken
+	return nil
+}
@@ -15,3 +15,38 @@ limitations under the License.
 */
 
 package logger
+
+import "time"
+
+// Configuration represents configuration of logging
+type Configuration struct {
+	// LogLevel sets logging level to show. Possible values are "debug",
+	// "info", "warn", "error", "fatal" and "panic", info level is used
+	// when not set
+	LogLevel string `mapstructure:"log_level" toml:"log_level"`
+	// Format of the log messages, either "json" or "console" (human
+	// readable colored output), JSON is used when not set
+	Format string `mapstructure:"format" toml:"format"`
+	// CloudWatch configures shipping of log messages to CloudWatch
+	CloudWatch CloudWatchConfiguration `mapstructure:"cloudwatch" toml:"cloudwatch"`
+}
+
+// CloudWatchConfiguration represents configuration of CloudWatch logger
+type CloudWatchConfiguration struct {
+	Enabled                 bool   `mapstructure:"enabled" toml:"enabled"`
+	LogGroup                string `mapstructure:"log_group" toml:"log_group"`
+	StreamName              string `mapstructure:"stream_name" toml:"stream_name"`
+	CreateStreamIfNotExists bool   `mapstructure:"create_stream_if_not_exists" toml:"create_stream_if_not_exists"`
+	AWSRegion               string `mapstructure:"aws_region" toml:"aws_region"`
+	// AWSAccessID, AWSSecretKey and AWSSessionToken are static credentials,
+	// the default AWS credential chain is used when they are not set
+	AWSAccessID     string `mapstructure:"aws_access_id" toml:"aws_access_id"`
+	AWSSecretKey    string `mapstructure:"aws_secret_key" toml:"aws_secret_key"`
+	AWSSessionToken string `mapstructure:"aws_session_token" toml:"aws_session_token"`
+	// Endpoint overrides URL of CloudWatch Logs service, it is useful for
+	// testing against a local stand-in
+	Endpoint string `mapstructure:"endpoint" toml:"endpoint"`
+	// FlushInterval is the period of sending buffered log messages,
+	// one second is used when not set
+	FlushInterval time.Duration `mapstructure:"flush_interval" toml:"flush_interval"`
+}
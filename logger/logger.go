@@ -15,5 +15,76 @@ limitations under the License.
 */
 
 // Package logger contains the configuration structures needed to configure
-// the access to CloudWatch server to sending the log messages there
+// the access to CloudWatch server to sending the log messages there. It also
+// initializes the global zerolog logger according to the configuration.
 package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// InitZerolog initializes global zerolog logger with provided configuration
+// to use standard output and, optionally, CloudWatch. Returned function
+// sends buffered log messages and needs to be called before exit.
+func InitZerolog(config Configuration) (func(), error) {
+	return InitZerologWithOutput(config, os.Stdout)
+}
+
+// InitZerologWithOutput initializes global zerolog logger the same way as
+// InitZerolog, but writes to given output instead of standard output
+func InitZerologWithOutput(config Configuration, output io.Writer) (func(), error) {
+	level, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	writers := []io.Writer{}
+
+	switch strings.ToLower(config.Format) {
+	case "", "json":
+		writers = append(writers, output)
+	case "console":
+		writers = append(writers, zerolog.ConsoleWriter{Out: output})
+	default:
+		return nil, fmt.Errorf("unknown log format '%s', use either json or console", config.Format)
+	}
+
+	closeLogger := func() {}
+
+	if config.CloudWatch.Enabled {
+		cloudWatchWriter, err := NewCloudWatchWriter(config.CloudWatch)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, cloudWatchWriter)
+		closeLogger = func() {
+			_ = cloudWatchWriter.Close()
+		}
+	}
+
+	// level is set on the application logger only, global level would filter
+	// out events of other loggers (audit log) as well
+	log.Logger = zerolog.New(zerolog.MultiLevelWriter(writers...)).Level(level).With().Timestamp().Logger()
+
+	return closeLogger, nil
+}
+
+// parseLogLevel parses log level from configuration, info level is used when not set
+func parseLogLevel(logLevel string) (zerolog.Level, error) {
+	if logLevel == "" {
+		return zerolog.InfoLevel, nil
+	}
+
+	level, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(logLevel)))
+	if err != nil || level == zerolog.NoLevel {
+		return zerolog.NoLevel, fmt.Errorf("unknown log level '%s'", logLevel)
+	}
+
+	return level, nil
+}
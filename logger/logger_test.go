@@ -0,0 +1,226 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/logger"
+)
+
+// cloudWatchStandIn records requests sent to CloudWatch Logs API
+type cloudWatchStandIn struct {
+	mutex          sync.Mutex
+	targets        []string
+	messages       []string
+	authorizations []string
+	// release, when set, holds PutLogEvents calls until it is closed
+	release chan struct{}
+}
+
+func (standIn *cloudWatchStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.Header.Get("X-Amz-Target")
+	if target == "Logs_20140328.PutLogEvents" && standIn.release != nil {
+		<-standIn.release
+	}
+
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+
+	standIn.targets = append(standIn.targets, target)
+	standIn.authorizations = append(standIn.authorizations, r.Header.Get("Authorization"))
+
+	if target == "Logs_20140328.PutLogEvents" {
+		body, _ := ioutil.ReadAll(r.Body)
+		input := struct {
+			LogEvents []struct {
+				Message string `json:"message"`
+			} `json:"logEvents"`
+		}{}
+		_ = json.Unmarshal(body, &input)
+		for _, event := range input.LogEvents {
+			standIn.messages = append(standIn.messages, event.Message)
+		}
+		_, _ = w.Write([]byte(`{"nextSequenceToken": "token"}`))
+		return
+	}
+
+	_, _ = w.Write([]byte(`{}`))
+}
+
+func resetLogger() {
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	log.Logger = zerolog.New(ioutil.Discard)
+}
+
+func TestInitZerologJSON(t *testing.T) {
+	defer resetLogger()
+	var output bytes.Buffer
+
+	closeLogger, err := logger.InitZerologWithOutput(logger.Configuration{LogLevel: "warn"}, &output)
+	assert.NoError(t, err)
+	defer closeLogger()
+
+	log.Info().Msg("filtered out")
+	log.Warn().Msg("logged")
+
+	event := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &event))
+	assert.Equal(t, "warn", event["level"])
+	assert.Equal(t, "logged", event["message"])
+}
+
+func TestInitZerologConsole(t *testing.T) {
+	defer resetLogger()
+	var output bytes.Buffer
+
+	closeLogger, err := logger.InitZerologWithOutput(logger.Configuration{Format: "console"}, &output)
+	assert.NoError(t, err)
+	defer closeLogger()
+
+	log.Info().Msg("logged")
+
+	assert.Contains(t, output.String(), "logged")
+	assert.False(t, json.Valid(output.Bytes()))
+}
+
+func TestInitZerologUnknownFormat(t *testing.T) {
+	_, err := logger.InitZerologWithOutput(logger.Configuration{Format: "xml"}, ioutil.Discard)
+
+	assert.EqualError(t, err, "unknown log format 'xml', use either json or console")
+}
+
+func TestInitZerologUnknownLevel(t *testing.T) {
+	_, err := logger.InitZerologWithOutput(logger.Configuration{LogLevel: "verbose"}, ioutil.Discard)
+
+	assert.EqualError(t, err, "unknown log level 'verbose'")
+}
+
+func TestInitZerologCloudWatch(t *testing.T) {
+	defer resetLogger()
+	standIn := &cloudWatchStandIn{}
+	standInServer := httptest.NewServer(standIn)
+	defer standInServer.Close()
+
+	closeLogger, err := logger.InitZerologWithOutput(logger.Configuration{
+		CloudWatch: logger.CloudWatchConfiguration{
+			Enabled:                 true,
+			LogGroup:                "group",
+			StreamName:              "stream",
+			CreateStreamIfNotExists: true,
+			AWSRegion:               "us-east-1",
+			AWSAccessID:             "access-id",
+			AWSSecretKey:            "secret-key",
+			Endpoint:                standInServer.URL,
+			FlushInterval:           time.Hour,
+		},
+	}, ioutil.Discard)
+	assert.NoError(t, err)
+
+	log.Info().Msg("first")
+	log.Info().Msg("second")
+
+	// messages are sent when the logger is closed at latest
+	closeLogger()
+
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+
+	assert.Equal(t, []string{"Logs_20140328.CreateLogStream", "Logs_20140328.PutLogEvents"}, standIn.targets)
+	assert.Len(t, standIn.messages, 2)
+	assert.Contains(t, standIn.messages[0], `"message":"first"`)
+	assert.Contains(t, standIn.messages[1], `"message":"second"`)
+}
+
+func TestCloudWatchWriteDoesNotWaitForCloudWatch(t *testing.T) {
+	standIn := &cloudWatchStandIn{release: make(chan struct{})}
+	standInServer := httptest.NewServer(standIn)
+	defer standInServer.Close()
+
+	writer, err := logger.NewCloudWatchWriter(logger.CloudWatchConfiguration{
+		LogGroup:      "group",
+		StreamName:    "stream",
+		AWSRegion:     "us-east-1",
+		AWSAccessID:   "access-id",
+		AWSSecretKey:  "secret-key",
+		Endpoint:      standInServer.URL,
+		FlushInterval: time.Hour,
+	})
+	assert.NoError(t, err)
+
+	// several full batches are written while CloudWatch does not respond
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i := 0; i < 3000; i++ {
+			_, _ = writer.Write([]byte("message"))
+		}
+	}()
+
+	select {
+	case <-written:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Write is blocked by CloudWatch call")
+	}
+
+	close(standIn.release)
+	assert.NoError(t, writer.Close())
+
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+	assert.Len(t, standIn.messages, 3000)
+}
+
+func TestCloudWatchDefaultCredentials(t *testing.T) {
+	standIn := &cloudWatchStandIn{}
+	standInServer := httptest.NewServer(standIn)
+	defer standInServer.Close()
+
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	assert.NoError(t, os.Setenv("AWS_ACCESS_KEY_ID", "environment-id"))
+	assert.NoError(t, os.Setenv("AWS_SECRET_ACCESS_KEY", "environment-key"))
+
+	writer, err := logger.NewCloudWatchWriter(logger.CloudWatchConfiguration{
+		LogGroup:      "group",
+		StreamName:    "stream",
+		AWSRegion:     "us-east-1",
+		Endpoint:      standInServer.URL,
+		FlushInterval: time.Hour,
+	})
+	assert.NoError(t, err)
+
+	_, _ = writer.Write([]byte("message"))
+	assert.NoError(t, writer.Close())
+
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+	assert.Len(t, standIn.authorizations, 1)
+	assert.Contains(t, standIn.authorizations[0], "Credential=environment-id/")
+}
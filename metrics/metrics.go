@@ -16,4 +16,172 @@ limitations under the License.
 
 // Package metrics contains all metrics that needs to be exposed to Prometheus
 // and indirectly to Grafana. Currently, the following metrics are exposed:
+//
+// api_endpoints_requests - number of requests by endpoint, method and status code
+//
+// api_endpoints_response_time - histogram of response times by endpoint and method
+//
+// api_endpoints_requests_in_flight - number of requests being served
+//
+// authentication_decisions_total - number of authentication decisions by outcome and reason
+//
+// authorization_decisions_total - number of authorization decisions by outcome and reason
+//
+// content_rules - number of loaded rules by status
+//
+// content_error_keys - number of loaded error keys by status
+//
+// content_tags - number of tags used by loaded content
+//
+// content_groups - number of loaded groups
+//
+// content_parse_errors_total - number of content parse errors
+//
+// content_last_load_parse_errors - number of parse errors during the last content load
+//
+// content_last_successful_load_timestamp_seconds - time of the last successful content load
+//
+// content_load_duration_seconds - histogram of content load durations by outcome
+//
+// content_version_info - version and source revision of the loaded content
 package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// APIEndpointsRequests shows number of requests related to API endpoints
+var APIEndpointsRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "api_endpoints_requests",
+	Help: "The total number of requests per endpoint, method and status code",
+}, []string{"endpoint", "method", "status_code"})
+
+// APIRequestsResponseTime collects the information about API response time per endpoint
+var APIRequestsResponseTime = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "api_endpoints_response_time",
+	Help:    "API endpoints response time in seconds",
+	Buckets: prometheus.DefBuckets,
+}, []string{"endpoint", "method"})
+
+// APIRequestsInFlight shows number of requests being served at the moment
+var APIRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "api_endpoints_requests_in_flight",
+	Help: "The number of requests being served",
+})
+
+// AuthenticationDecisions shows number of authentication decisions made by
+// auth. middleware, labeled by outcome (success or failure) and reason code
+var AuthenticationDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "authentication_decisions_total",
+	Help: "The total number of authentication decisions by outcome and reason",
+}, []string{"outcome", "reason"})
+
+// AuthorizationDecisions shows number of authorization decisions made for
+// authenticated identities, labeled by outcome (success or failure) and
+// reason code
+var AuthorizationDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "authorization_decisions_total",
+	Help: "The total number of authorization decisions by outcome and reason",
+}, []string{"outcome", "reason"})
+
+// ContentRules shows number of loaded rules by their status
+var ContentRules = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "content_rules",
+	Help: "The number of loaded rules by status",
+}, []string{"status"})
+
+// ContentErrorKeys shows number of loaded error keys by their status
+var ContentErrorKeys = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "content_error_keys",
+	Help: "The number of loaded error keys by status",
+}, []string{"status"})
+
+// ContentTags shows number of distinct tags used by loaded content
+var ContentTags = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "content_tags",
+	Help: "The number of tags used by loaded content",
+})
+
+// ContentGroups shows number of loaded groups
+var ContentGroups = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "content_groups",
+	Help: "The number of loaded groups",
+})
+
+// ContentParseErrors shows total number of content parse errors
+var ContentParseErrors = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "content_parse_errors_total",
+	Help: "The total number of content parse errors",
+})
+
+// ContentLastLoadParseErrors shows number of parse errors during the last content load
+var ContentLastLoadParseErrors = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "content_last_load_parse_errors",
+	Help: "The number of parse errors during the last content load",
+})
+
+// ContentLastSuccessfulLoad shows time of the last successful content load
+var ContentLastSuccessfulLoad = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "content_last_successful_load_timestamp_seconds",
+	Help: "Unix time of the last successful content load",
+})
+
+// ContentLoadDuration collects the information about content load duration
+var ContentLoadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "content_load_duration_seconds",
+	Help:    "Content load duration in seconds by outcome",
+	Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+}, []string{"outcome"})
+
+// ContentVersionInfo is always set to 1 for the version and source revision
+// of the loaded content
+var ContentVersionInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "content_version_info",
+	Help: "Version and source revision of the loaded content",
+}, []string{"version", "revision"})
+
+// ContentLoadStats summarizes content loaded into the service
+type ContentLoadStats struct {
+	RulesByStatus     map[string]int
+	ErrorKeysByStatus map[string]int
+	Tags              int
+	Groups            int
+	ParseErrors       int
+	Version           string
+	Revision          string
+}
+
+// RecordContentLoad updates content inventory metrics after an attempt to
+// load content. Inventory of the previously loaded content is kept when the
+// load fails.
+func RecordContentLoad(stats ContentLoadStats, duration time.Duration, loadErr error) {
+	ContentParseErrors.Add(float64(stats.ParseErrors))
+	ContentLastLoadParseErrors.Set(float64(stats.ParseErrors))
+
+	if loadErr != nil {
+		ContentLoadDuration.WithLabelValues("failure").Observe(duration.Seconds())
+		return
+	}
+	ContentLoadDuration.WithLabelValues("success").Observe(duration.Seconds())
+
+	ContentRules.Reset()
+	for status, count := range stats.RulesByStatus {
+		ContentRules.WithLabelValues(status).Set(float64(count))
+	}
+
+	ContentErrorKeys.Reset()
+	for status, count := range stats.ErrorKeysByStatus {
+		ContentErrorKeys.WithLabelValues(status).Set(float64(count))
+	}
+
+	ContentTags.Set(float64(stats.Tags))
+	ContentGroups.Set(float64(stats.Groups))
+
+	ContentVersionInfo.Reset()
+	ContentVersionInfo.WithLabelValues(stats.Version, stats.Revision).Set(1)
+
+	ContentLastSuccessfulLoad.SetToCurrentTime()
+}
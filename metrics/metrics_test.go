@@ -13,3 +13,66 @@
 // limitations under the License.
 
 package metrics_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/metrics"
+)
+
+var testContentStats = metrics.ContentLoadStats{
+	RulesByStatus:     map[string]int{"active": 10, "inactive": 2},
+	ErrorKeysByStatus: map[string]int{"active": 15},
+	Tags:              7,
+	Groups:            3,
+	ParseErrors:       1,
+	Version:           "1.2.3",
+	Revision:          "abcdef",
+}
+
+func TestRecordContentLoad(t *testing.T) {
+	parseErrorsBefore := testutil.ToFloat64(metrics.ContentParseErrors)
+
+	metrics.RecordContentLoad(testContentStats, time.Second, nil)
+
+	assert.Equal(t, float64(10), testutil.ToFloat64(metrics.ContentRules.WithLabelValues("active")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.ContentRules.WithLabelValues("inactive")))
+	assert.Equal(t, float64(15), testutil.ToFloat64(metrics.ContentErrorKeys.WithLabelValues("active")))
+	assert.Equal(t, float64(7), testutil.ToFloat64(metrics.ContentTags))
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.ContentGroups))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ContentLastLoadParseErrors))
+	assert.Equal(t, parseErrorsBefore+1, testutil.ToFloat64(metrics.ContentParseErrors))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ContentVersionInfo.WithLabelValues("1.2.3", "abcdef")))
+	assert.InDelta(t, float64(time.Now().Unix()), testutil.ToFloat64(metrics.ContentLastSuccessfulLoad), 5)
+}
+
+func TestContentParseErrorsName(t *testing.T) {
+	metrics.RecordContentLoad(testContentStats, time.Second, nil)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	assert.Contains(t, names, "content_parse_errors_total")
+	assert.NotContains(t, names, "content_parse_errors")
+}
+
+func TestRecordContentLoadFailureKeepsInventory(t *testing.T) {
+	metrics.RecordContentLoad(testContentStats, time.Second, nil)
+
+	metrics.RecordContentLoad(metrics.ContentLoadStats{ParseErrors: 5}, time.Second, errors.New("load failed"))
+
+	assert.Equal(t, float64(10), testutil.ToFloat64(metrics.ContentRules.WithLabelValues("active")))
+	assert.Equal(t, float64(7), testutil.ToFloat64(metrics.ContentTags))
+	assert.Equal(t, float64(5), testutil.ToFloat64(metrics.ContentLastLoadParseErrors))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.ContentVersionInfo))
+}
@@ -0,0 +1,370 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package search contains in-memory full-text index of rule content. The
+// index is built when the content is loaded and it is never modified, so it
+// can be searched concurrently.
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Indexed fields of rule content
+const (
+	FieldSummary     = "summary"
+	FieldReason      = "reason"
+	FieldResolution  = "resolution"
+	FieldDescription = "description"
+	FieldGeneric     = "generic"
+)
+
+// DefaultBoosts weight matches in short descriptive fields more than matches
+// in long texts
+var DefaultBoosts = map[string]float64{
+	FieldSummary:     3,
+	FieldDescription: 2,
+	FieldReason:      1,
+	FieldResolution:  1,
+	FieldGeneric:     1,
+}
+
+const (
+	// snippetContext is approximate number of bytes shown before the
+	// first match in highlighted snippet
+	snippetContext = 40
+	// snippetLength is approximate length of highlighted snippet in bytes
+	snippetLength = 160
+
+	highlightStart = "<em>"
+	highlightEnd   = "</em>"
+	ellipsis       = "…"
+)
+
+// Document is one searchable item, a rule error key for example
+type Document struct {
+	ID     string
+	Fields map[string]string
+}
+
+// Result is a document matching search query. Highlights contain snippets
+// of matching fields with HTML escaped text and matches wrapped in <em> tags.
+type Result struct {
+	ID         string            `json:"id"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// location is a field of indexed document
+type location struct {
+	document int
+	field    string
+}
+
+// Index is inverted index of documents
+type Index struct {
+	documents []Document
+	boosts    map[string]float64
+	// postings map terms to positions of their occurrences
+	postings map[string]map[location][]int
+}
+
+// query is parsed search query, phrases are enclosed in double quotes
+type query struct {
+	terms   []string
+	phrases [][]string
+}
+
+// NewIndex builds index of given documents, matches in fields are weighted
+// by boosts and fields without boost have weight 1
+func NewIndex(documents []Document, boosts map[string]float64) *Index {
+	index := &Index{
+		documents: documents,
+		boosts:    boosts,
+		postings:  map[string]map[location][]int{},
+	}
+
+	for i, document := range documents {
+		for field, text := range document.Fields {
+			for position, token := range tokenize(text) {
+				locations, found := index.postings[token.term]
+				if !found {
+					locations = map[location][]int{}
+					index.postings[token.term] = locations
+				}
+
+				loc := location{document: i, field: field}
+				locations[loc] = append(locations[loc], position)
+			}
+		}
+	}
+
+	return index
+}
+
+// Size returns number of indexed documents
+func (index *Index) Size() int {
+	return len(index.documents)
+}
+
+// Search returns at most limit documents matching query ordered by relevance.
+// Document matches when it contains all phrases of the query, documents are
+// matched by any of the query words when the query has no phrases.
+func (index *Index) Search(text string, limit int) []Result {
+	parsed := parseQuery(text)
+	scores := map[int]float64{}
+
+	for _, term := range parsed.terms {
+		index.scoreTerm(term, scores)
+	}
+
+	if len(parsed.phrases) > 0 {
+		scores = index.scorePhrases(parsed.phrases, scores)
+	}
+
+	results := make([]Result, 0, len(scores))
+	for document, score := range scores {
+		results = append(results, Result{
+			ID:         index.documents[document].ID,
+			Score:      score,
+			Highlights: index.highlight(document, parsed),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// parseQuery splits query into phrases and separate words, stop words are
+// dropped unless they are part of a phrase
+func parseQuery(text string) query {
+	var parsed query
+
+	for i, part := range strings.Split(text, `"`) {
+		terms := terms(part)
+
+		// odd parts are enclosed in quotes
+		if i%2 == 1 && len(terms) > 1 {
+			parsed.phrases = append(parsed.phrases, terms)
+			continue
+		}
+
+		for _, term := range terms {
+			if !stopWords[term] {
+				parsed.terms = append(parsed.terms, term)
+			}
+		}
+	}
+
+	return parsed
+}
+
+func terms(text string) []string {
+	var terms []string
+	for _, token := range tokenize(text) {
+		terms = append(terms, token.term)
+	}
+	return terms
+}
+
+// scoreTerm adds score of term to scores of all documents containing it
+func (index *Index) scoreTerm(term string, scores map[int]float64) {
+	idf := index.idf(term)
+
+	for loc, positions := range index.postings[term] {
+		frequency := 1 + math.Log(float64(len(positions)))
+		scores[loc.document] += index.boost(loc.field) * frequency * idf
+	}
+}
+
+// scorePhrases returns scores of documents containing all phrases, matched
+// phrases add to the scores of the query words
+func (index *Index) scorePhrases(phrases [][]string, termScores map[int]float64) map[int]float64 {
+	var scores map[int]float64
+
+	for _, phrase := range phrases {
+		matches := index.matchPhrase(phrase)
+
+		phraseScores := map[int]float64{}
+		for document, fields := range matches {
+			if scores != nil {
+				if _, found := scores[document]; !found {
+					continue
+				}
+			}
+
+			phraseScores[document] = scores[document]
+			for _, field := range fields {
+				for _, term := range phrase {
+					phraseScores[document] += index.boost(field) * index.idf(term)
+				}
+			}
+		}
+
+		scores = phraseScores
+	}
+
+	for document := range scores {
+		scores[document] += termScores[document]
+	}
+
+	return scores
+}
+
+// idf is inverse document frequency of term, rare terms are weighted more
+func (index *Index) idf(term string) float64 {
+	documents := map[int]bool{}
+	for loc := range index.postings[term] {
+		documents[loc.document] = true
+	}
+
+	if len(documents) == 0 {
+		return 0
+	}
+
+	return math.Log(1 + float64(len(index.documents))/float64(len(documents)))
+}
+
+func (index *Index) boost(field string) float64 {
+	if boost, found := index.boosts[field]; found {
+		return boost
+	}
+	return 1
+}
+
+// matchPhrase returns documents containing the phrase together with fields
+// the phrase was found in
+func (index *Index) matchPhrase(phrase []string) map[int][]string {
+	matches := map[int][]string{}
+
+	for loc, positions := range index.postings[phrase[0]] {
+		for _, position := range positions {
+			if index.phraseAt(phrase, loc, position) {
+				matches[loc.document] = append(matches[loc.document], loc.field)
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// phraseAt checks whether the phrase starts at given position
+func (index *Index) phraseAt(phrase []string, loc location, position int) bool {
+	for offset, term := range phrase[1:] {
+		if !containsInt(index.postings[term][loc], position+offset+1) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight returns snippets of document fields containing any query term
+func (index *Index) highlight(document int, parsed query) map[string]string {
+	highlighted := map[string]bool{}
+	for _, term := range parsed.terms {
+		highlighted[term] = true
+	}
+	for _, phrase := range parsed.phrases {
+		for _, term := range phrase {
+			if !stopWords[term] {
+				highlighted[term] = true
+			}
+		}
+	}
+
+	highlights := map[string]string{}
+	for field, text := range index.documents[document].Fields {
+		if snippet, found := snippet(text, highlighted); found {
+			highlights[field] = snippet
+		}
+	}
+
+	return highlights
+}
+
+// snippet returns part of text around the first highlighted term
+func snippet(text string, highlighted map[string]bool) (string, bool) {
+	tokens := tokenize(text)
+
+	first := -1
+	for i, token := range tokens {
+		if highlighted[token.term] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	// snippet starts and ends at word boundaries, short texts are
+	// not shortened at all
+	start := first
+	for start > 0 && (len(text) <= snippetLength || tokens[first].start-tokens[start-1].start <= snippetContext) {
+		start--
+	}
+	end := first
+	for end+1 < len(tokens) && tokens[end+1].end-tokens[start].start <= snippetLength {
+		end++
+	}
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString(ellipsis)
+	}
+
+	offset := tokens[start].start
+	for _, token := range tokens[start : end+1] {
+		builder.WriteString(html.EscapeString(text[offset:token.start]))
+		if highlighted[token.term] {
+			builder.WriteString(highlightStart + html.EscapeString(text[token.start:token.end]) + highlightEnd)
+		} else {
+			builder.WriteString(html.EscapeString(text[token.start:token.end]))
+		}
+		offset = token.end
+	}
+
+	if end+1 < len(tokens) {
+		builder.WriteString(ellipsis)
+	} else {
+		builder.WriteString(html.EscapeString(text[offset:]))
+	}
+
+	return builder.String(), true
+}
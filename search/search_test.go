@@ -0,0 +1,183 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/search"
+)
+
+var documents = []search.Document{
+	{
+		ID: "etcd_disk_latency|SLOW_DISK",
+		Fields: map[string]string{
+			search.FieldSummary:    "Etcd reports high disk latency",
+			search.FieldReason:     "The etcd members have slow disks, fsync duration exceeds 10ms.",
+			search.FieldResolution: "Move etcd to faster disks, SSDs are recommended.",
+		},
+	},
+	{
+		ID: "nodes_kubelet_version_check|NODE_KUBELET_VERSION",
+		Fields: map[string]string{
+			search.FieldSummary:     "Kubelet version of nodes does not match",
+			search.FieldDescription: "Nodes running different kubelet versions can cause latency <spikes>",
+		},
+	},
+	{
+		ID: "cluster_wide_proxy_auth_check|AUTH_OPERATOR_PROXY_ERROR",
+		Fields: map[string]string{
+			search.FieldSummary: "Authentication operator can not connect through the proxy",
+			search.FieldGeneric: "The disk of the proxy is not related to the etcd cluster",
+		},
+	},
+}
+
+func ids(results []search.Result) []string {
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
+
+func TestSearchRanksByRelevance(t *testing.T) {
+	index := search.NewIndex(documents, search.DefaultBoosts)
+
+	results := index.Search("the recommendation about etcd disk latency", 0)
+
+	assert.Equal(t, []string{
+		"etcd_disk_latency|SLOW_DISK",
+		"cluster_wide_proxy_auth_check|AUTH_OPERATOR_PROXY_ERROR",
+		"nodes_kubelet_version_check|NODE_KUBELET_VERSION",
+	}, ids(results))
+}
+
+func TestSearchStemming(t *testing.T) {
+	index := search.NewIndex(documents, search.DefaultBoosts)
+
+	assert.Equal(t, []string{"nodes_kubelet_version_check|NODE_KUBELET_VERSION"}, ids(index.Search("matching kubelet node", 0)))
+	assert.Equal(t, []string{"etcd_disk_latency|SLOW_DISK"}, ids(index.Search("recommend SSD", 0)))
+	assert.Equal(t, []string{"nodes_kubelet_version_check|NODE_KUBELET_VERSION"}, ids(index.Search("running", 0)))
+}
+
+func TestSearchPhrase(t *testing.T) {
+	index := search.NewIndex(documents, search.DefaultBoosts)
+
+	assert.Equal(t, []string{"etcd_disk_latency|SLOW_DISK"}, ids(index.Search(`"disk latency"`, 0)))
+	assert.Equal(t, []string{"cluster_wide_proxy_auth_check|AUTH_OPERATOR_PROXY_ERROR"},
+		ids(index.Search(`etcd "disk of the proxy"`, 0)))
+	assert.Empty(t, index.Search(`"latency disk"`, 0))
+	assert.Empty(t, index.Search(`"disk latency" "kubelet version"`, 0))
+}
+
+func TestSearchFieldBoosts(t *testing.T) {
+	boosted := []search.Document{
+		{ID: "in-reason", Fields: map[string]string{search.FieldReason: "proxy"}},
+		{ID: "in-summary", Fields: map[string]string{search.FieldSummary: "proxy"}},
+	}
+
+	index := search.NewIndex(boosted, search.DefaultBoosts)
+	assert.Equal(t, []string{"in-summary", "in-reason"}, ids(index.Search("proxy", 0)))
+
+	index = search.NewIndex(boosted, map[string]float64{search.FieldReason: 10})
+	assert.Equal(t, []string{"in-reason", "in-summary"}, ids(index.Search("proxy", 0)))
+}
+
+func TestSearchLimit(t *testing.T) {
+	index := search.NewIndex(documents, search.DefaultBoosts)
+
+	assert.Len(t, index.Search("etcd disk latency", 2), 2)
+	assert.Equal(t, 3, index.Size())
+}
+
+func TestSearchNoMatch(t *testing.T) {
+	index := search.NewIndex(documents, search.DefaultBoosts)
+
+	assert.Empty(t, index.Search("prometheus", 0))
+	assert.Empty(t, index.Search("the", 0))
+	assert.Empty(t, index.Search("", 0))
+}
+
+func TestSearchHighlights(t *testing.T) {
+	index := search.NewIndex(documents, search.DefaultBoosts)
+
+	results := index.Search("latency", 0)
+
+	assert.Equal(t, map[string]string{
+		search.FieldSummary: "Etcd reports high disk <em>latency</em>",
+	}, results[0].Highlights)
+	assert.Equal(t, map[string]string{
+		search.FieldDescription: "Nodes running different kubelet versions can cause <em>latency</em> &lt;spikes&gt;",
+	}, results[1].Highlights)
+}
+
+func TestSearchHighlightsLongText(t *testing.T) {
+	text := "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt " +
+		"ut labore et dolore magna aliqua. The etcd disk is slow. Ut enim ad minim veniam, quis nostrud " +
+		"exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor in " +
+		"reprehenderit in voluptate velit esse cillum dolore eu fugiat nulla pariatur."
+	index := search.NewIndex([]search.Document{{ID: "long", Fields: map[string]string{search.FieldReason: text}}}, nil)
+
+	highlight := index.Search("etcd", 0)[0].Highlights[search.FieldReason]
+
+	assert.Equal(t, "…ut labore et dolore magna aliqua. The <em>etcd</em> disk is slow. Ut enim ad minim veniam, "+
+		"quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo…", highlight)
+}
+
+func TestSearchWordForms(t *testing.T) {
+	for _, tc := range []struct {
+		text  string
+		query string
+	}{
+		{"Invalid kubelet settings cause warnings", "setting"},
+		{"Invalid kubelet settings cause warnings", "warning"},
+		{"Invalid kubelet setting causes warning", "settings warnings"},
+		{"Operator reports the configured proxies", "proxy configure"},
+		{"Proxy policy is not applied", "policies applies"},
+		{"Disk speeds are low", "speed"},
+		{"Disk speed is low", "speeds"},
+		{"Nodes are running", "run"},
+		{"Certificates expired", "expires"},
+	} {
+		index := search.NewIndex([]search.Document{{
+			ID:     "document",
+			Fields: map[string]string{search.FieldSummary: tc.text},
+		}}, search.DefaultBoosts)
+
+		assert.Equal(t, []string{"document"}, ids(index.Search(tc.query, 0)), tc.query)
+	}
+}
+
+func TestSearchShortStems(t *testing.T) {
+	for _, tc := range []struct {
+		text  string
+		query string
+	}{
+		{"Disk speed is low", "sp"},
+		{"Invalid string value", "str"},
+	} {
+		index := search.NewIndex([]search.Document{{
+			ID:     "document",
+			Fields: map[string]string{search.FieldSummary: tc.text},
+		}}, search.DefaultBoosts)
+
+		assert.Empty(t, index.Search(tc.query, 0), tc.query)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is one word of indexed text, start and end are byte offsets of the
+// word in the text
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// stopWords are not used to look up documents, they are still indexed so
+// phrases containing them can be matched
+var stopWords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true,
+	"for": true, "in": true, "is": true, "of": true, "on": true,
+	"or": true, "the": true, "to": true, "with": true,
+}
+
+// tokenize splits text into words and normalizes them into terms
+func tokenize(text string) []token {
+	var tokens []token
+
+	start := -1
+	for offset, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = offset
+		}
+		if !isWordRune && start >= 0 {
+			tokens = append(tokens, newToken(text, start, offset))
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, newToken(text, start, len(text)))
+	}
+
+	return tokens
+}
+
+func newToken(text string, start, end int) token {
+	return token{term: stem(strings.ToLower(text[start:end])), start: start, end: end}
+}
+
+// minStemLength is the minimal number of letters left by stemming
+const minStemLength = 3
+
+// stem strips common English suffixes, so different forms of one word are
+// matched. The plural suffix is stripped first, so plurals are reduced the
+// same way as their singular forms. It is intentionally simple, words are
+// never shortened below minStemLength letters and suffixes are kept when
+// the rest of the word contains no vowel.
+func stem(word string) string {
+	word = singular(word)
+
+	for _, suffix := range []string{"ing", "ed", "ly"} {
+		// "speed" or "need" are not past tense
+		if suffix == "ed" && strings.HasSuffix(word, "eed") {
+			continue
+		}
+		if stemmed, ok := stripSuffix(word, suffix, ""); ok {
+			word = undouble(stemmed)
+			break
+		}
+	}
+
+	// "configure" and "configured" are matched
+	word, _ = stripSuffix(word, "e", "")
+
+	return word
+}
+
+// singular strips plural suffix of the word
+func singular(word string) string {
+	for _, rule := range []struct{ suffix, replacement string }{
+		{"sses", "ss"},
+		{"ies", "y"},
+	} {
+		if stemmed, ok := stripSuffix(word, rule.suffix, rule.replacement); ok {
+			return stemmed
+		}
+	}
+
+	if strings.HasSuffix(word, "ss") || strings.HasSuffix(word, "us") || strings.HasSuffix(word, "is") {
+		return word
+	}
+
+	stemmed, _ := stripSuffix(word, "s", "")
+	return stemmed
+}
+
+// stripSuffix replaces suffix of the word, the word is returned unchanged
+// when the stem would be too short or would contain no vowel
+func stripSuffix(word, suffix, replacement string) (string, bool) {
+	if !strings.HasSuffix(word, suffix) {
+		return word, false
+	}
+	stemmed := strings.TrimSuffix(word, suffix)
+	if utf8.RuneCountInString(stemmed) < minStemLength || !strings.ContainsAny(stemmed, "aeiouy") {
+		return word, false
+	}
+	return stemmed + replacement, true
+}
+
+// undouble reduces double consonant left by stripped suffix ("setting" ->
+// "set"), except for consonants doubled in the base form ("installed")
+func undouble(word string) string {
+	length := len(word)
+	if length <= minStemLength || word[length-1] != word[length-2] || strings.ContainsRune("aeiouylsz", rune(word[length-1])) {
+		return word
+	}
+	return word[:length-1]
+}
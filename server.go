@@ -18,19 +18,34 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/RedHatInsights/insights-content-service/conf"
+	"github.com/RedHatInsights/insights-content-service/logger"
+	"github.com/RedHatInsights/insights-content-service/server"
+	"github.com/RedHatInsights/insights-content-service/tracing"
+	"github.com/RedHatInsights/insights-content-service/types"
 )
 
 const (
 	// ExitStatusOK means that the tool finished with success
 	ExitStatusOK = iota
+	// ExitStatusServerError means that the HTTP server cannot be initialized
+	ExitStatusServerError
+	// ExitStatusForcedShutdown means that in-flight requests have not
+	// finished in time during shutdown and their connections were closed
+	ExitStatusForcedShutdown
 
 	defaultConfigFilename = "config"
 )
@@ -51,7 +66,107 @@ var (
 
 // startService starts service and returns error code
 func startService() int {
-	return ExitStatusOK
+	serverCfg := conf.GetServerConfiguration()
+
+	apiKeys, err := conf.GetAPIKeys()
+	if err != nil {
+		log.Error().Err(err).Msg("API keys loading error")
+		return ExitStatusServerError
+	}
+
+	serverInstance := server.New(serverCfg)
+	serverInstance.APIKeys = apiKeys
+	serverInstance.BuildInfo = server.BuildInfo{
+		Version: BuildVersion,
+		Time:    BuildTime,
+		Branch:  BuildBranch,
+		Commit:  BuildCommit,
+	}
+
+	serverInstance.ContentReload = func() error {
+		reloadContent()
+		return nil
+	}
+
+	if serverCfg.Debug {
+		serverInstance.DebugConfig = conf.GetRedactedConfiguration()
+	}
+
+	// audit events are written to standard output when no file is configured
+	if serverCfg.AuditLogFile != "" {
+		auditLogFile, err := server.OpenAuditLogFile(serverCfg.AuditLogFile)
+		if err != nil {
+			log.Error().Err(err).Msg("Audit log file opening error")
+			return ExitStatusServerError
+		}
+		defer closeFile(auditLogFile)
+
+		serverInstance.AuditLog = server.NewAuditLogger(auditLogFile)
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- serverInstance.Start()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case err := <-serverErrors:
+			if err != nil {
+				log.Error().Err(err).Msg("HTTP(s) start error")
+				return ExitStatusServerError
+			}
+			return ExitStatusOK
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				reloadContent()
+				continue
+			}
+
+			log.Info().Msgf("Received %v signal, shutting down", sig)
+			return shutdown(serverInstance, serverErrors)
+		}
+	}
+}
+
+// shutdown stops the server gracefully and waits until it stops, files
+// opened by startService are closed after that
+func shutdown(serverInstance *server.HTTPServer, serverErrors <-chan error) int {
+	exitCode := ExitStatusOK
+
+	err := serverInstance.Shutdown()
+	if err == server.ErrForcedShutdown {
+		exitCode = ExitStatusForcedShutdown
+	} else if err != nil {
+		log.Error().Err(err).Msg("HTTP server shutdown error")
+		exitCode = ExitStatusServerError
+	}
+
+	if err := <-serverErrors; err != nil {
+		log.Error().Err(err).Msg("HTTP server error")
+		return ExitStatusServerError
+	}
+
+	return exitCode
+}
+
+// reloadContent is called on SIGHUP and by content reload endpoint
+func reloadContent() {
+	_, span := tracing.StartContentLoad(context.Background(), tracing.ContentLoadTriggerReload)
+	defer span.End()
+
+	log.Info().Msg("Content reload requested, but the service does not load any content yet")
+}
+
+func closeFile(file *os.File) {
+	err := file.Close()
+	if err != nil {
+		log.Error().Err(err).Msgf("Unable to close file '%s'", file.Name())
+	}
 }
 
 func initInfoLog(msg string) {
@@ -80,6 +195,11 @@ The commands are:
     print-help          prints help
     print-config        prints current configuration set by files & env variables
     print-version-info  prints version info
+    generate-api-key    generates new static API key for internal service,
+                        run with -h to see all options
+    generate-token      generates x-rh-identity header or JWT token for local
+                        development, run with -h to see all options; the
+                        service does not verify JWT signature nor expiration
 
 `
 
@@ -89,7 +209,7 @@ func printHelp() int {
 }
 
 func printConfig() int {
-	configBytes, err := json.MarshalIndent(conf.Config, "", "    ")
+	configBytes, err := json.MarshalIndent(conf.GetRedactedConfiguration(), "", "    ")
 
 	if err != nil {
 		log.Error().Err(err)
@@ -101,19 +221,174 @@ func printConfig() int {
 	return 0
 }
 
+// generateAPIKey generates new static API key and prints both the key and
+// the entry to be stored in API keys file
+func generateAPIKey(args []string) int {
+	flags := flag.NewFlagSet("generate-api-key", flag.ContinueOnError)
+	service := flags.String("service", "", "name of the service the key is generated for")
+	expiresIn := flags.Duration("expires-in", 0, "validity of the key, the key never expires when not set")
+	routes := flags.String("routes", "", "comma-separated list of endpoints the key can be used for, all endpoints when not set")
+	roles := flags.String("roles", "", "comma-separated list of roles granted to the service")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if *service == "" {
+		fmt.Println("Service name needs to be specified by -service option")
+		return 1
+	}
+
+	key, hash, err := server.GenerateAPIKey()
+	if err != nil {
+		log.Error().Err(err).Msg("API key generation error")
+		return 1
+	}
+
+	apiKey := server.APIKey{
+		Service: *service,
+		Hash:    hash,
+		Routes:  splitList(*routes),
+	}
+
+	for _, role := range splitList(*roles) {
+		apiKey.Roles = append(apiKey.Roles, server.Role(role))
+	}
+
+	if *expiresIn > 0 {
+		expires := time.Now().UTC().Add(*expiresIn).Truncate(time.Second)
+		apiKey.Expires = &expires
+	}
+
+	entry, err := server.EncodeAPIKeys([]server.APIKey{apiKey})
+	if err != nil {
+		log.Error().Err(err).Msg("API key encoding error")
+		return 1
+	}
+
+	fmt.Printf("API key for service '%v' (it can not be retrieved later):\n\n    %v\n\n", *service, key)
+	fmt.Printf("Entry for API keys file:\n\n%v", entry)
+
+	return 0
+}
+
+// generateToken generates x-rh-identity header or signed JWT token and
+// prints it in the form of curl option. The service does not verify JWT
+// signature nor expiration, the tokens are signed only so they can be
+// passed to tools that do.
+func generateToken(args []string) int {
+	flags := flag.NewFlagSet("generate-token", flag.ContinueOnError)
+	authType := flags.String("auth-type", "xrh", "type of the token, either xrh or jwt")
+	orgID := flags.Uint("org-id", 1, "organization ID")
+	account := flags.String("account", "1", "account number")
+	identityType := flags.String("identity-type", "User", "type of the identity")
+	username := flags.String("username", "", "name of the user")
+	expiresIn := flags.Duration("expires-in", 24*time.Hour, "value of exp claim of JWT token, the claim is not set when 0 (the service itself does not check it)")
+	keyFile := flags.String("key-file", "", "RSA private key in PEM format or HMAC secret used to sign JWT token (the service itself does not verify the signature)")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	switch *authType {
+	case "xrh":
+		token, err := server.NewXRHIdentityToken(server.Identity{
+			Type:          *identityType,
+			AccountNumber: types.UserID(*account),
+			Internal:      server.Internal{OrgID: types.OrgID(*orgID)},
+			User:          server.User{Username: *username},
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Token generation error")
+			return 1
+		}
+
+		fmt.Printf("-H \"x-rh-identity: %v\"\n", token)
+	case "jwt":
+		if *keyFile == "" {
+			fmt.Println("Key file needs to be specified by -key-file option")
+			return 1
+		}
+
+		// #nosec G304
+		key, err := ioutil.ReadFile(*keyFile)
+		if err != nil {
+			log.Error().Err(err).Msg("Key file reading error")
+			return 1
+		}
+
+		token, err := server.NewJWT(server.JWTPayload{
+			Type:          *identityType,
+			AccountNumber: types.UserID(*account),
+			OrgID:         types.OrgID(*orgID),
+			Username:      *username,
+		}, *expiresIn, key)
+		if err != nil {
+			log.Error().Err(err).Msg("Token generation error")
+			return 1
+		}
+
+		fmt.Printf("-H \"Authorization: Bearer %v\"\n", token)
+	default:
+		fmt.Printf("Unknown token type '%v', use either xrh or jwt\n", *authType)
+		return 1
+	}
+
+	return 0
+}
+
+// splitList splits comma-separated list of values, empty values are skipped
+func splitList(list string) []string {
+	var values []string
+
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// commandArgs returns arguments following the command name
+func commandArgs() []string {
+	if len(os.Args) > 2 {
+		return os.Args[2:]
+	}
+	return nil
+}
+
 func main() {
 	err := conf.LoadConfiguration(defaultConfigFilename)
 	if err != nil {
 		panic(err)
 	}
 
+	// logger has to be initialized before any message (including version info) is logged
+	closeLogger, err := logger.InitZerolog(conf.GetLoggingConfiguration())
+	if err != nil {
+		panic(err)
+	}
+
+	closeTracing, err := tracing.InitTracing(conf.GetTracingConfiguration())
+	if err != nil {
+		panic(err)
+	}
+
 	command := "start-service"
 
 	if len(os.Args) >= 2 {
 		command = strings.ToLower(strings.TrimSpace(os.Args[1]))
 	}
 
-	os.Exit(handleCommand(command))
+	exitCode := handleCommand(command)
+
+	// buffered spans and log messages needs to be sent before exit
+	closeTracing()
+	closeLogger()
+
+	os.Exit(exitCode)
 }
 
 func handleCommand(command string) int {
@@ -132,6 +407,10 @@ func handleCommand(command string) int {
 		return printConfig()
 	case "print-version-info":
 		printVersionInfo()
+	case "generate-api-key":
+		return generateAPIKey(commandArgs())
+	case "generate-token":
+		return generateToken(commandArgs())
 	default:
 		fmt.Printf("\nCommand '%v' not found\n", command)
 		return printHelp()
@@ -0,0 +1,177 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/RedHatInsights/insights-content-service/types"
+)
+
+const (
+	// AccessLogFormatJSON is access log format with one JSON object per line
+	AccessLogFormatJSON = "json"
+	// AccessLogFormatCombined is Apache combined log format
+	AccessLogFormatCombined = "combined"
+
+	// ContextKeyAccessLog is a constant for access log entry in request context
+	ContextKeyAccessLog = contextKey("access_log")
+
+	// combinedTimeFormat is the time format used by Apache
+	combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+)
+
+// accessLogEntry is one line of access log, the identity is filled in by
+// authentication that happens deeper in the middleware chain
+type accessLogEntry struct {
+	Time       time.Time   `json:"time"`
+	RemoteAddr string      `json:"remote_addr"`
+	OrgID      types.OrgID `json:"org_id,omitempty"`
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	Protocol   string      `json:"protocol"`
+	Status     int         `json:"status"`
+	Bytes      int         `json:"bytes"`
+	DurationMs float64     `json:"duration_ms"`
+	Referer    string      `json:"referer,omitempty"`
+	UserAgent  string      `json:"user_agent,omitempty"`
+	RequestID  string      `json:"request_id,omitempty"`
+}
+
+// newAccessLogWriter returns writer for access log specified in
+// configuration, it is either standard output or rotated file
+func newAccessLogWriter(config AccessLogConfiguration) io.Writer {
+	if config.Output == "" || config.Output == "stdout" {
+		return os.Stdout
+	}
+
+	return &lumberjack.Logger{
+		Filename:   config.Output,
+		MaxSize:    config.MaxSize,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAge,
+	}
+}
+
+// AccessLog middleware writes one line per request to access log
+func (server *HTTPServer) AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if stringInSlice(r.URL.Path, server.Config.AccessLog.SkipPaths) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		entry := &accessLogEntry{
+			Time:       time.Now(),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Path:       r.URL.RequestURI(),
+			Protocol:   r.Proto,
+			Referer:    r.Referer(),
+			UserAgent:  r.UserAgent(),
+			RequestID:  GetRequestID(r),
+		}
+
+		writer := &statusWriter{ResponseWriter: w}
+		ctx := context.WithValue(r.Context(), ContextKeyAccessLog, entry)
+
+		next.ServeHTTP(writer, r.WithContext(ctx))
+
+		entry.Status = writer.Status()
+		entry.Bytes = writer.length
+		entry.DurationMs = float64(time.Since(entry.Time).Microseconds()) / 1000
+
+		server.writeAccessLog(entry)
+	})
+}
+
+// recordAccessLogIdentity stores organization ID of the identity into access
+// log entry of the request
+func recordAccessLogIdentity(request *http.Request, identity Identity) {
+	if entry, ok := request.Context().Value(ContextKeyAccessLog).(*accessLogEntry); ok {
+		entry.OrgID = identity.Internal.OrgID
+	}
+}
+
+func (server *HTTPServer) writeAccessLog(entry *accessLogEntry) {
+	var line []byte
+
+	if server.Config.AccessLog.Format == AccessLogFormatCombined {
+		line = []byte(formatCombined(entry))
+	} else {
+		var err error
+		line, err = json.Marshal(entry)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to encode access log entry")
+			return
+		}
+		line = append(line, '\n')
+	}
+
+	_, err := server.accessLogWriter.Write(line)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to write access log entry")
+	}
+}
+
+// formatCombined formats access log entry in Apache combined log format,
+// the organization ID is used in place of the user name
+func formatCombined(entry *accessLogEntry) string {
+	host, _, err := net.SplitHostPort(entry.RemoteAddr)
+	if err != nil {
+		host = entry.RemoteAddr
+	}
+
+	user := "-"
+	if entry.OrgID != 0 {
+		user = strconv.FormatUint(uint64(entry.OrgID), 10)
+	}
+
+	size := "-"
+	if entry.Bytes > 0 {
+		size = strconv.Itoa(entry.Bytes)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] %q %d %s %q %q\n",
+		host,
+		user,
+		entry.Time.Format(combinedTimeFormat),
+		entry.Method+" "+entry.Path+" "+entry.Protocol,
+		entry.Status,
+		size,
+		dashIfEmpty(entry.Referer),
+		dashIfEmpty(entry.UserAgent),
+	)
+}
+
+func dashIfEmpty(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+// accessLogConfig returns configuration with access log written to a file
+// in temporary directory
+func accessLogConfig(t *testing.T, format string) (server.Configuration, string) {
+	dir, err := ioutil.TempDir("", "access-log")
+	assert.NoError(t, err)
+
+	cfg := authConfig()
+	cfg.AccessLog = server.AccessLogConfiguration{
+		Enabled:   true,
+		Format:    format,
+		Output:    filepath.Join(dir, "access.log"),
+		SkipPaths: []string{config.APIPrefix + server.MetricsEndpoint},
+	}
+	return cfg, dir
+}
+
+func readAccessLog(t *testing.T, cfg server.Configuration) string {
+	content, err := ioutil.ReadFile(cfg.AccessLog.Output)
+	if os.IsNotExist(err) {
+		return ""
+	}
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	cfg, dir := accessLogConfig(t, server.AccessLogFormatJSON)
+	defer os.RemoveAll(dir)
+
+	req := orgRequest(t, 42)
+	req.RemoteAddr = "10.0.0.1:1234"
+	executeRequest(server.New(cfg), req)
+
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(readAccessLog(t, cfg)), &entry))
+	assert.Equal(t, "10.0.0.1:1234", entry["remote_addr"])
+	assert.Equal(t, float64(42), entry["org_id"])
+	assert.Equal(t, http.MethodGet, entry["method"])
+	assert.Equal(t, config.APIPrefix, entry["path"])
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+	assert.Greater(t, entry["bytes"], float64(0))
+	assert.Contains(t, entry, "duration_ms")
+	assert.NotEmpty(t, entry["request_id"])
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	cfg, dir := accessLogConfig(t, server.AccessLogFormatCombined)
+	defer os.RemoveAll(dir)
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "curl/7.69.1")
+	executeRequest(server.New(cfg), req)
+
+	assert.Regexp(t,
+		`^10\.0\.0\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /api/test/ HTTP/1\.1" 403 \d+ "-" "curl/7\.69\.1"\n$`,
+		readAccessLog(t, cfg))
+}
+
+func TestAccessLogCombinedWithOrg(t *testing.T) {
+	cfg, dir := accessLogConfig(t, server.AccessLogFormatCombined)
+	defer os.RemoveAll(dir)
+
+	executeRequest(server.New(cfg), orgRequest(t, 42))
+
+	assert.Regexp(t, `^192\.0\.2\.1 - 42 \[`, readAccessLog(t, cfg))
+}
+
+func TestAccessLogUnmatchedRequests(t *testing.T) {
+	cfg, dir := accessLogConfig(t, server.AccessLogFormatJSON)
+	defer os.RemoveAll(dir)
+	cfg.CORS = server.CORSConfiguration{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://preview.example.com"},
+		MaxAge:         time.Minute,
+	}
+	testServer := server.New(cfg)
+
+	preflight := httptest.NewRequest(http.MethodOptions, config.APIPrefix, nil)
+	preflight.Header.Set("Origin", "https://preview.example.com")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, config.APIPrefix+"unknown", nil),
+		httptest.NewRequest(http.MethodPost, config.APIPrefix, nil),
+		preflight,
+	} {
+		executeRequest(testServer, req)
+	}
+
+	statuses := []float64{}
+	for _, line := range strings.Split(strings.TrimSpace(readAccessLog(t, cfg)), "\n") {
+		entry := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.NotEmpty(t, entry["request_id"])
+		statuses = append(statuses, entry["status"].(float64))
+	}
+
+	assert.Equal(t, []float64{http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNoContent}, statuses)
+}
+
+func TestAccessLogSkipPaths(t *testing.T) {
+	cfg, dir := accessLogConfig(t, server.AccessLogFormatJSON)
+	defer os.RemoveAll(dir)
+
+	executeRequest(server.New(cfg), httptest.NewRequest(http.MethodGet, config.APIPrefix+server.MetricsEndpoint, nil))
+
+	assert.Empty(t, readAccessLog(t, cfg))
+}
+
+func TestAccessLogDisabled(t *testing.T) {
+	cfg, dir := accessLogConfig(t, server.AccessLogFormatJSON)
+	defer os.RemoveAll(dir)
+	cfg.AccessLog.Enabled = false
+
+	executeRequest(server.New(cfg), orgRequest(t, 42))
+
+	assert.Empty(t, readAccessLog(t, cfg))
+}
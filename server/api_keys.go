@@ -0,0 +1,166 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	// APIKeyHeader is the name of HTTP header with static API key
+	APIKeyHeader = "X-API-Key"
+
+	// ServiceIdentityType is the type of identities assigned to callers
+	// authenticated by static API key
+	ServiceIdentityType = "Service"
+
+	// ServiceUsernamePrefix is prepended to the service name of the API key
+	// to get username of the service identity, so the API keys are not
+	// matched by role bindings of users
+	ServiceUsernamePrefix = "service:"
+
+	// apiKeyLength is number of random bytes in generated API keys
+	apiKeyLength = 32
+
+	// #nosec G101
+	invalidAPIKeyMessage = "Invalid API key"
+)
+
+// APIKey represents a static API key used by internal services. Only the
+// SHA-256 hash of the key is stored.
+type APIKey struct {
+	// Service is the name of the service the key belongs to
+	Service string `toml:"service"`
+	// Hash is the hex encoded SHA-256 hash of the key
+	Hash string `toml:"hash"`
+	// Expires is the time after which the key is no longer accepted,
+	// the key never expires when it is not set
+	Expires *time.Time `toml:"expires,omitempty"`
+	// Routes lists endpoints (without API prefix) the key can be used
+	// for, all endpoints are allowed when the list is empty
+	Routes []string `toml:"routes,omitempty"`
+	// Roles are granted to the service identity in addition to reader
+	Roles []Role `toml:"roles,omitempty"`
+}
+
+// apiKeysFile has exactly the same structure as API keys file
+type apiKeysFile struct {
+	APIKeys []APIKey `toml:"api_keys"`
+}
+
+// ParseAPIKeys parses API keys from TOML document
+func ParseAPIKeys(data string) ([]APIKey, error) {
+	var keys apiKeysFile
+
+	_, err := toml.Decode(data, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys.APIKeys, nil
+}
+
+// EncodeAPIKeys encodes API keys into TOML document that can be parsed by ParseAPIKeys
+func EncodeAPIKeys(keys []APIKey) (string, error) {
+	var buffer bytes.Buffer
+
+	err := toml.NewEncoder(&buffer).Encode(apiKeysFile{APIKeys: keys})
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// HashAPIKey returns hex encoded SHA-256 hash of given API key
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateAPIKey generates new random API key and returns it together with its hash
+func GenerateAPIKey() (key, hash string, err error) {
+	randomBytes := make([]byte, apiKeyLength)
+
+	_, err = rand.Read(randomBytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	key = base64.RawURLEncoding.EncodeToString(randomBytes)
+	return key, HashAPIKey(key), nil
+}
+
+// findAPIKey returns API key with hash matching given key
+func (server *HTTPServer) findAPIKey(key string) (APIKey, bool) {
+	hash := []byte(HashAPIKey(key))
+
+	for _, apiKey := range server.APIKeys {
+		if subtle.ConstantTimeCompare(hash, []byte(apiKey.Hash)) == 1 {
+			return apiKey, true
+		}
+	}
+
+	return APIKey{}, false
+}
+
+// authenticateAPIKey checks the API key sent by caller and, if the key is
+// valid for the requested endpoint, returns the request with service identity
+func (server *HTTPServer) authenticateAPIKey(w http.ResponseWriter, r *http.Request, key string) (*http.Request, bool) {
+	apiKey, found := server.findAPIKey(key)
+	if !found {
+		server.rejectRequest(w, r, AuditReasonInvalidAPIKey, Identity{},
+			&AuthenticationError{errString: invalidAPIKeyMessage})
+		return nil, false
+	}
+
+	identity := Identity{
+		Type:  ServiceIdentityType,
+		User:  User{Username: ServiceUsernamePrefix + apiKey.Service},
+		Roles: apiKey.Roles,
+	}
+
+	if apiKey.Expires != nil && time.Now().After(*apiKey.Expires) {
+		const message = "Expired API key"
+		server.rejectRequest(w, r, AuditReasonExpiredAPIKey, identity,
+			&AuthenticationError{errString: message})
+		return nil, false
+	}
+
+	if len(apiKey.Routes) > 0 {
+		endpoint, found := server.endpointName(r)
+		if !found || !stringInSlice(endpoint, apiKey.Routes) {
+			const message = "API key is not allowed to access this endpoint"
+			server.rejectRequest(w, r, AuditReasonAPIKeyOutOfScope, identity,
+				&AuthenticationError{errString: message})
+			return nil, false
+		}
+	}
+
+	server.auditAuthentication(r, AuditReasonValidAPIKey, identity, nil)
+
+	return withIdentity(r, identity), true
+}
@@ -0,0 +1,152 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+const testAPIKey = "test-api-key"
+
+func apiKeyServer(apiKey server.APIKey) *server.HTTPServer {
+	apiKey.Hash = server.HashAPIKey(testAPIKey)
+
+	srv := server.New(authConfig())
+	srv.APIKeys = []server.APIKey{apiKey}
+	return srv
+}
+
+func apiKeyRequest(key string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set(server.APIKeyHeader, key)
+	return req
+}
+
+func TestHashAPIKey(t *testing.T) {
+	assert.Equal(t,
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		server.HashAPIKey(""))
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, hash, err := server.GenerateAPIKey()
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, key)
+	assert.Equal(t, server.HashAPIKey(key), hash)
+}
+
+func TestEncodeParseAPIKeys(t *testing.T) {
+	expires := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	keys := []server.APIKey{{
+		Service: "smart-proxy",
+		Hash:    server.HashAPIKey(testAPIKey),
+		Expires: &expires,
+		Routes:  []string{"info"},
+		Roles:   []server.Role{server.RoleOperator},
+	}, {
+		Service: "batch",
+		Hash:    server.HashAPIKey("other-key"),
+	}}
+
+	document, err := server.EncodeAPIKeys(keys)
+	assert.NoError(t, err)
+
+	parsed, err := server.ParseAPIKeys(document)
+	assert.NoError(t, err)
+	assert.Equal(t, keys, parsed)
+}
+
+func TestParseAPIKeysMalformed(t *testing.T) {
+	_, err := server.ParseAPIKeys("[[api_keys]\n")
+
+	assert.Error(t, err)
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	rr := executeRequest(apiKeyServer(server.APIKey{Service: "smart-proxy"}), apiKeyRequest(testAPIKey))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAPIKeyInvalid(t *testing.T) {
+	rr := executeRequest(apiKeyServer(server.APIKey{Service: "smart-proxy"}), apiKeyRequest("wrong-key"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, `{"status": "Invalid API key"}`, rr.Body.String())
+}
+
+func TestAPIKeyExpired(t *testing.T) {
+	expires := time.Now().Add(-time.Hour)
+
+	rr := executeRequest(apiKeyServer(server.APIKey{Service: "smart-proxy", Expires: &expires}), apiKeyRequest(testAPIKey))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, `{"status": "Expired API key"}`, rr.Body.String())
+}
+
+func TestAPIKeyNotExpired(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+
+	rr := executeRequest(apiKeyServer(server.APIKey{Service: "smart-proxy", Expires: &expires}), apiKeyRequest(testAPIKey))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAPIKeyOutOfScope(t *testing.T) {
+	rr := executeRequest(apiKeyServer(server.APIKey{Service: "smart-proxy", Routes: []string{"info"}}), apiKeyRequest(testAPIKey))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, `{"status": "API key is not allowed to access this endpoint"}`, rr.Body.String())
+}
+
+func TestAPIKeyInScope(t *testing.T) {
+	rr := executeRequest(apiKeyServer(server.APIKey{Service: "smart-proxy", Routes: []string{server.MainEndpoint}}), apiKeyRequest(testAPIKey))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAPIKeyNotMatchedByUserRoleBindings(t *testing.T) {
+	testConfig := authConfig()
+	testConfig.Debug = true
+	testConfig.RoleBindings = []server.RoleBinding{
+		{Role: server.RoleOperator, Usernames: []string{"smart-proxy"}},
+	}
+
+	for _, tc := range []struct {
+		roles  []server.Role
+		status int
+	}{
+		{nil, http.StatusForbidden},
+		{[]server.Role{server.RoleOperator}, http.StatusOK},
+	} {
+		srv := server.New(testConfig)
+		srv.APIKeys = []server.APIKey{{Service: "smart-proxy", Hash: server.HashAPIKey(testAPIKey), Roles: tc.roles}}
+
+		req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.DebugRoutesEndpoint, nil)
+		req.Header.Set(server.APIKeyHeader, testAPIKey)
+
+		assert.Equal(t, tc.status, executeRequest(srv, req).Code)
+	}
+}
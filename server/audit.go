@@ -0,0 +1,146 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/rs/zerolog"
+
+	"github.com/RedHatInsights/insights-content-service/metrics"
+)
+
+// Outcomes of authentication decisions
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// Reason codes of authentication and authorization decisions
+const (
+	AuditReasonValidToken             = "valid_token"
+	AuditReasonValidAPIKey            = "valid_api_key"
+	AuditReasonValidClientCertificate = "valid_client_certificate"
+	AuditReasonMissingToken           = "missing_token"
+	AuditReasonMalformedToken         = "malformed_token"
+	AuditReasonInvalidAPIKey          = "invalid_api_key"
+	AuditReasonExpiredAPIKey          = "expired_api_key"
+	AuditReasonAPIKeyOutOfScope       = "api_key_out_of_scope"
+	AuditReasonRoleGranted            = "role_granted"
+	AuditReasonInsufficientRoles      = "insufficient_roles"
+	AuditReasonMissingIdentity        = "missing_identity"
+)
+
+const (
+	auditAuthTypeAPIKey            = "api_key"
+	auditAuthTypeClientCertificate = "client_certificate"
+	auditAuthTypeJWT               = "jwt"
+	auditAuthTypeXRH               = "xrh"
+
+	auditAuthenticationEventMessage = "Authentication decision"
+	auditAuthorizationEventMessage  = "Authorization decision"
+)
+
+// NewAuditLogger constructs logger for audit events writing to given sink
+func NewAuditLogger(writer io.Writer) zerolog.Logger {
+	return zerolog.New(writer).With().Timestamp().Str("type", "audit").Logger()
+}
+
+// OpenAuditLogFile opens (or creates) file the audit events are appended to
+func OpenAuditLogFile(path string) (*os.File, error) {
+	// #nosec G302 G304
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+}
+
+// authType returns the type of authentication used for the request
+func (server *HTTPServer) authType(r *http.Request) string {
+	if _, found := clientCertificate(r); found {
+		return auditAuthTypeClientCertificate
+	}
+	if r.Header.Get(APIKeyHeader) != "" {
+		return auditAuthTypeAPIKey
+	}
+	if server.Config.AuthType == "jwt" {
+		return auditAuthTypeJWT
+	}
+	return auditAuthTypeXRH
+}
+
+// auditAuthentication writes audit event describing authentication decision
+// made for given request, counts it in Prometheus metrics and records it in
+// the trace of the request. The error is nil for successful decisions.
+func (server *HTTPServer) auditAuthentication(r *http.Request, reason string, identity Identity, err error) {
+	outcome, authType := server.writeAuditEvent(r, auditAuthenticationEventMessage, reason, identity, err)
+
+	metrics.AuthenticationDecisions.WithLabelValues(outcome, reason).Inc()
+	recordAuthenticationDecision(r, outcome, reason, authType)
+}
+
+// auditAuthorization counts authorization decision in Prometheus metrics.
+// Only denied requests are written to audit log, granted ones are covered
+// by the authentication event already.
+func (server *HTTPServer) auditAuthorization(r *http.Request, reason string, identity Identity, err error) {
+	outcome := AuditOutcomeSuccess
+	if err != nil {
+		outcome, _ = server.writeAuditEvent(r, auditAuthorizationEventMessage, reason, identity, err)
+	}
+
+	metrics.AuthorizationDecisions.WithLabelValues(outcome, reason).Inc()
+}
+
+// writeAuditEvent writes audit event with given message and returns outcome
+// and authentication type of the decision
+func (server *HTTPServer) writeAuditEvent(r *http.Request, message, reason string, identity Identity, err error) (outcome, authType string) {
+	outcome = AuditOutcomeSuccess
+	event := server.AuditLog.Info()
+	if err != nil {
+		outcome = AuditOutcomeFailure
+		event = server.AuditLog.Warn().Err(err)
+	}
+
+	authType = server.authType(r)
+
+	event.
+		Str("outcome", outcome).
+		Str("reason", reason).
+		Str("auth_type", authType).
+		Uint32("org_id", uint32(identity.Internal.OrgID)).
+		Str("account", string(identity.AccountNumber)).
+		Str("username", identity.User.Username).
+		Str("remote_addr", r.RemoteAddr).
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Str("request_id", GetRequestID(r)).
+		Msg(message)
+
+	return outcome, authType
+}
+
+// rejectRequest audits the failed authentication decision and sends error response
+func (server *HTTPServer) rejectRequest(w http.ResponseWriter, r *http.Request, reason string, identity Identity, err error) {
+	server.auditAuthentication(r, reason, identity, err)
+	handleServerError(w, r, err)
+}
+
+// rejectUnauthorized audits the failed authorization decision and sends
+// error response
+func (server *HTTPServer) rejectUnauthorized(w http.ResponseWriter, r *http.Request, reason string, identity Identity, err error) {
+	server.auditAuthorization(r, reason, identity, err)
+	handleServerError(w, r, err)
+}
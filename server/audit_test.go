@@ -0,0 +1,183 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/logger"
+	"github.com/RedHatInsights/insights-content-service/metrics"
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+// auditedRequest executes request against server with auth. enabled and
+// returns the single audit event written during request processing
+func auditedRequest(t *testing.T, req *http.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var auditLog bytes.Buffer
+
+	srv := server.New(authConfig())
+	srv.AuditLog = server.NewAuditLogger(&auditLog)
+	srv.APIKeys = []server.APIKey{{Service: "smart-proxy", Hash: server.HashAPIKey(testAPIKey)}}
+
+	rr := executeRequest(srv, req)
+
+	event := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(auditLog.Bytes(), &event))
+
+	return rr, event
+}
+
+func TestAuditSuccessfulAuthentication(t *testing.T) {
+	counter := metrics.AuthenticationDecisions.WithLabelValues(server.AuditOutcomeSuccess, server.AuditReasonValidToken)
+	before := testutil.ToFloat64(counter)
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set(server.RequestIDHeader, "request-1")
+	req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{
+		AccountNumber: "42",
+		Internal:      server.Internal{OrgID: 1},
+	}))
+
+	rr, event := auditedRequest(t, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "audit", event["type"])
+	assert.Equal(t, "info", event["level"])
+	assert.Equal(t, server.AuditOutcomeSuccess, event["outcome"])
+	assert.Equal(t, server.AuditReasonValidToken, event["reason"])
+	assert.Equal(t, "xrh", event["auth_type"])
+	assert.Equal(t, float64(1), event["org_id"])
+	assert.Equal(t, "42", event["account"])
+	assert.Equal(t, "10.0.0.1:1234", event["remote_addr"])
+	assert.Equal(t, config.APIPrefix, event["path"])
+	assert.Equal(t, "request-1", event["request_id"])
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestAuditMissingToken(t *testing.T) {
+	counter := metrics.AuthenticationDecisions.WithLabelValues(server.AuditOutcomeFailure, server.AuditReasonMissingToken)
+	before := testutil.ToFloat64(counter)
+
+	rr, event := auditedRequest(t, httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "warn", event["level"])
+	assert.Equal(t, server.AuditOutcomeFailure, event["outcome"])
+	assert.Equal(t, server.AuditReasonMissingToken, event["reason"])
+	assert.Equal(t, "Missing auth token", event["error"])
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestAuditIgnoresApplicationLogLevel(t *testing.T) {
+	defer func(original zerolog.Logger) { log.Logger = original }(log.Logger)
+
+	closeLogger, err := logger.InitZerologWithOutput(logger.Configuration{LogLevel: "error"}, ioutil.Discard)
+	assert.NoError(t, err)
+	defer closeLogger()
+
+	rr, event := auditedRequest(t, httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "warn", event["level"])
+	assert.Equal(t, server.AuditReasonMissingToken, event["reason"])
+}
+
+func TestAuditMalformedToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set("x-rh-identity", "not a base64 token")
+
+	_, event := auditedRequest(t, req)
+
+	assert.Equal(t, server.AuditReasonMalformedToken, event["reason"])
+}
+
+func TestAuditAPIKey(t *testing.T) {
+	_, event := auditedRequest(t, apiKeyRequest(testAPIKey))
+
+	assert.Equal(t, server.AuditOutcomeSuccess, event["outcome"])
+	assert.Equal(t, server.AuditReasonValidAPIKey, event["reason"])
+	assert.Equal(t, "api_key", event["auth_type"])
+	assert.Equal(t, server.ServiceUsernamePrefix+"smart-proxy", event["username"])
+}
+
+func TestAuditInvalidAPIKey(t *testing.T) {
+	_, event := auditedRequest(t, apiKeyRequest("wrong-key"))
+
+	assert.Equal(t, server.AuditOutcomeFailure, event["outcome"])
+	assert.Equal(t, server.AuditReasonInvalidAPIKey, event["reason"])
+}
+
+func TestAuditAuthorizationFailure(t *testing.T) {
+	authorizationFailures := metrics.AuthorizationDecisions.WithLabelValues(server.AuditOutcomeFailure, server.AuditReasonInsufficientRoles)
+	authenticationFailures := metrics.AuthenticationDecisions.WithLabelValues(server.AuditOutcomeFailure, server.AuditReasonInsufficientRoles)
+	authenticationSuccesses := metrics.AuthenticationDecisions.WithLabelValues(server.AuditOutcomeSuccess, server.AuditReasonValidToken)
+	failuresBefore := testutil.ToFloat64(authorizationFailures)
+	successesBefore := testutil.ToFloat64(authenticationSuccesses)
+
+	testConfig := authConfig()
+	testConfig.Debug = true
+
+	var auditLog bytes.Buffer
+	srv := server.New(testConfig)
+	srv.AuditLog = server.NewAuditLogger(&auditLog)
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.DebugRoutesEndpoint, nil)
+	req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{AccountNumber: "1", Internal: server.Internal{OrgID: 1}}))
+
+	rr := executeRequest(srv, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// authentication succeeded, only authorization failed
+	assert.Equal(t, successesBefore+1, testutil.ToFloat64(authenticationSuccesses))
+	assert.Equal(t, float64(0), testutil.ToFloat64(authenticationFailures))
+	assert.Equal(t, failuresBefore+1, testutil.ToFloat64(authorizationFailures))
+
+	lines := bytes.Split(bytes.TrimSpace(auditLog.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+
+	event := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(lines[1], &event))
+	assert.Equal(t, "Authorization decision", event["message"])
+	assert.Equal(t, server.AuditOutcomeFailure, event["outcome"])
+	assert.Equal(t, server.AuditReasonInsufficientRoles, event["reason"])
+}
+
+func TestAuditAuthorizationSuccessCounted(t *testing.T) {
+	counter := metrics.AuthorizationDecisions.WithLabelValues(server.AuditOutcomeSuccess, server.AuditReasonRoleGranted)
+	before := testutil.ToFloat64(counter)
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{AccountNumber: "1", Internal: server.Internal{OrgID: 1}}))
+
+	// only the authentication decision is written to audit log
+	rr, event := auditedRequest(t, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Authentication decision", event["message"])
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
@@ -20,13 +20,14 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 
 	jwt "github.com/dgrijalva/jwt-go"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/codes"
 
 	"github.com/RedHatInsights/insights-content-service/types"
 )
@@ -45,10 +46,21 @@ type Internal struct {
 	OrgID types.OrgID `json:"org_id,string"`
 }
 
+// User contains information about the user the identity belongs to
+type User struct {
+	Username string `json:"username"`
+}
+
 // Identity contains internal user info
 type Identity struct {
+	Type          string       `json:"type,omitempty"`
 	AccountNumber types.UserID `json:"account_number"`
 	Internal      Internal     `json:"internal"`
+	User          User         `json:"user"`
+	// Roles are granted by server configuration, to API keys for example.
+	// They are never read from tokens, which are not verified by the
+	// service and could grant any role to their bearer otherwise.
+	Roles []Role `json:"-"`
 }
 
 // Token is x-rh-identity struct
@@ -58,8 +70,10 @@ type Token struct {
 
 // JWTPayload is structure that contain data from parsed JWT token
 type JWTPayload struct {
+	Type          string       `json:"type,omitempty"`
 	AccountNumber types.UserID `json:"account_number"`
 	OrgID         types.OrgID  `json:"org_id,string"`
+	Username      string       `json:"username"`
 }
 
 // Authentication middleware for checking auth rights
@@ -72,64 +86,154 @@ func (server *HTTPServer) Authentication(next http.Handler, noAuthURLs []string)
 			return
 		}
 
-		token, isTokenValid := server.getAuthTokenHeader(w, r)
-		if !isTokenValid {
+		authRequest, span := startAuthenticationSpan(r)
+		authenticated, ok := server.authenticate(w, authRequest)
+		if !ok {
+			span.SetStatus(codes.Error, "authentication failed")
+		}
+		span.End()
+
+		if !ok {
 			// everything has been handled already
 			return
 		}
 
-		decoded, err := jwt.DecodeSegment(token) // Decode token to JSON string
-		if err != nil {                          // Malformed token, returns with http code 403 as usual
-			log.Error().Err(err).Msg(malformedTokenMessage)
-			handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
-			return
+		// Everything went well, proceed with the request and set the caller to the user retrieved from the parsed token
+		next.ServeHTTP(w, withParentSpan(authenticated, r))
+	})
+}
+
+// authenticate checks credentials sent by caller and returns request with
+// the caller's identity stored in its context. The request is rejected when
+// the credentials are not valid.
+func (server *HTTPServer) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	// internal services connecting directly can be authenticated by TLS
+	// client certificate verified during handshake
+	if certificate, found := clientCertificate(r); found {
+		return server.authenticateClientCertificate(r, certificate)
+	}
+
+	// internal services authenticate by static API key instead of token
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return server.authenticateAPIKey(w, r, key)
+	}
+
+	token, isTokenValid := server.getAuthTokenHeader(w, r)
+	if !isTokenValid {
+		return nil, false
+	}
+
+	decoded, err := server.decodeToken(token) // Decode token to JSON string
+	if err != nil {                           // Malformed token, returns with http code 403 as usual
+		server.rejectMalformedToken(w, r, err)
+		return nil, false
+	}
+
+	tk := &Token{}
+
+	// If we took JWT token, it has different structure then x-rh-identity
+	if server.Config.AuthType == "jwt" {
+		jwt := &JWTPayload{}
+		err = json.Unmarshal([]byte(decoded), jwt)
+		if err != nil { //Malformed token, returns with http code 403 as usual
+			server.rejectMalformedToken(w, r, err)
+			return nil, false
+		}
+		// Map JWT token to inner token
+		tk.Identity = Identity{
+			Type:          jwt.Type,
+			AccountNumber: jwt.AccountNumber,
+			Internal:      Internal{OrgID: jwt.OrgID},
+			User:          User{Username: jwt.Username},
 		}
+	} else {
+		err = json.Unmarshal([]byte(decoded), tk)
 
-		tk := &Token{}
-
-		// If we took JWT token, it has different structure then x-rh-identity
-		if server.Config.AuthType == "jwt" {
-			jwt := &JWTPayload{}
-			err = json.Unmarshal([]byte(decoded), jwt)
-			if err != nil { //Malformed token, returns with http code 403 as usual
-				log.Error().Err(err).Msg(malformedTokenMessage)
-				handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
-				return
-			}
-			// Map JWT token to inner token
-			tk.Identity = Identity{AccountNumber: jwt.AccountNumber, Internal: Internal{OrgID: jwt.OrgID}}
-		} else {
-			err = json.Unmarshal([]byte(decoded), tk)
-
-			if err != nil { //Malformed token, returns with http code 403 as usual
-				log.Error().Err(err).Msg(malformedTokenMessage)
-				handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
-				return
-			}
+		if err != nil { //Malformed token, returns with http code 403 as usual
+			server.rejectMalformedToken(w, r, err)
+			return nil, false
 		}
+	}
 
-		// Everything went well, proceed with the request and set the caller to the user retrieved from the parsed token
-		ctx := context.WithValue(r.Context(), ContextKeyUser, tk.Identity)
-		r = r.WithContext(ctx)
+	server.auditAuthentication(r, AuditReasonValidToken, tk.Identity, nil)
 
-		next.ServeHTTP(w, r)
-	})
+	return withIdentity(r, tk.Identity), true
+}
+
+// decodeToken decodes payload of JWT token, which uses URL-safe base64
+// encoding without padding, or x-rh-identity header. Signature and
+// expiration of JWT tokens are not verified, so the payload can not be
+// trusted more than x-rh-identity header.
+func (server *HTTPServer) decodeToken(token string) ([]byte, error) {
+	if server.Config.AuthType == "jwt" {
+		return jwt.DecodeSegment(token)
+	}
+	return decodeXRHIdentity(token)
+}
+
+// decodeXRHIdentity decodes x-rh-identity header. The gateway encodes it by
+// standard base64 encoding, which can contain + and / characters rejected by
+// URL-safe decoding used for JWT segments. URL-safe encoding is accepted too,
+// so the headers accepted by previous versions keep working.
+func decodeXRHIdentity(token string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err == nil {
+		return decoded, nil
+	}
+
+	if decoded, urlErr := jwt.DecodeSegment(token); urlErr == nil {
+		return decoded, nil
+	}
+
+	return nil, err
+}
+
+// rejectMalformedToken rejects request with token that can not be decoded
+func (server *HTTPServer) rejectMalformedToken(w http.ResponseWriter, r *http.Request, err error) {
+	requestLogger(r).Debug().Err(err).Msg(malformedTokenMessage)
+	server.rejectRequest(w, r, AuditReasonMalformedToken, Identity{}, &AuthenticationError{errString: malformedTokenMessage})
+}
+
+// withIdentity returns request with given identity stored in its context.
+// The identity is also added to request logger and access log entry.
+func withIdentity(request *http.Request, identity Identity) *http.Request {
+	recordAccessLogIdentity(request, identity)
+
+	logger := requestLogger(request).With().
+		Uint32("org_id", uint32(identity.Internal.OrgID)).
+		Str("account", string(identity.AccountNumber)).
+		Logger()
+
+	ctx := context.WithValue(request.Context(), ContextKeyUser, identity)
+	ctx = logger.WithContext(ctx)
+
+	return request.WithContext(ctx)
 }
 
 // GetCurrentUserID retrieves current user's id from request
 func (server *HTTPServer) GetCurrentUserID(request *http.Request) (types.UserID, error) {
+	identity, err := server.GetCurrentIdentity(request)
+	if err != nil {
+		return "", err
+	}
+
+	return identity.AccountNumber, nil
+}
+
+// GetCurrentIdentity retrieves identity of the current user from request
+func (server *HTTPServer) GetCurrentIdentity(request *http.Request) (Identity, error) {
 	i := request.Context().Value(ContextKeyUser)
 
 	if i == nil {
-		return "", &AuthenticationError{errString: "user id is not provided"}
+		return Identity{}, &AuthenticationError{errString: "user id is not provided"}
 	}
 
 	identity, ok := i.(Identity)
 	if !ok {
-		return "", fmt.Errorf("contextKeyUser has wrong type")
+		return Identity{}, fmt.Errorf("contextKeyUser has wrong type")
 	}
 
-	return identity.AccountNumber, nil
+	return identity, nil
 }
 
 func (server *HTTPServer) getAuthTokenHeader(w http.ResponseWriter, r *http.Request) (string, bool) {
@@ -137,17 +241,6 @@ func (server *HTTPServer) getAuthTokenHeader(w http.ResponseWriter, r *http.Requ
 	// In case of testing on local machine we don't take x-rh-identity header, but instead Authorization with JWT token in it
 	if server.Config.AuthType == "jwt" {
 		tokenHeader = r.Header.Get("Authorization") //Grab the token from the header
-		splitted := strings.Split(tokenHeader, " ") //The token normally comes in format `Bearer {token-body}`, we check if the retrieved token matched this requirement
-		if len(splitted) != 2 {
-			const message = "Invalid/Malformed auth token"
-			log.Error().Msg(message)
-			handleServerError(w, &AuthenticationError{errString: message})
-			return "", false
-		}
-
-		// Here we take JWT token which include 3 parts, we need only second one
-		splitted = strings.Split(splitted[1], ".")
-		tokenHeader = splitted[1]
 	} else {
 		tokenHeader = r.Header.Get("x-rh-identity") //Grab the token from the header
 	}
@@ -155,10 +248,23 @@ func (server *HTTPServer) getAuthTokenHeader(w http.ResponseWriter, r *http.Requ
 	// TODO: Change SendUnauthorized in utils to accept string instead of map interface and change here
 	if tokenHeader == "" {
 		const message = "Missing auth token"
-		log.Error().Msg(message)
-		handleServerError(w, &AuthenticationError{errString: message})
+		server.rejectRequest(w, r, AuditReasonMissingToken, Identity{}, &AuthenticationError{errString: message})
 		return "", false
 	}
 
+	if server.Config.AuthType == "jwt" {
+		splitted := strings.Split(tokenHeader, " ") //The token normally comes in format `Bearer {token-body}`, we check if the retrieved token matched this requirement
+		if len(splitted) == 2 {
+			// Here we take JWT token which include 3 parts, we need only second one
+			splitted = strings.Split(splitted[1], ".")
+		}
+		if len(splitted) != 3 {
+			const message = "Invalid/Malformed auth token"
+			server.rejectRequest(w, r, AuditReasonMalformedToken, Identity{}, &AuthenticationError{errString: message})
+			return "", false
+		}
+		tokenHeader = splitted[1]
+	}
+
 	return tokenHeader, true
 }
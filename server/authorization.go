@@ -0,0 +1,158 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Role represents a set of permissions granted to an authenticated identity
+type Role string
+
+const (
+	// RoleReader is granted to every authenticated identity and allows
+	// reading of published content
+	RoleReader = Role("reader")
+	// RoleContentAdmin is granted to the content team and allows
+	// administrative operations with the content
+	RoleContentAdmin = Role("content-admin")
+	// RoleOperator is granted to the people operating the service
+	RoleOperator = Role("operator")
+)
+
+// routeRoles is a declarative table of roles allowed to access each endpoint.
+// Endpoints are identified by their path without API prefix. Access to an
+// endpoint that is not listed in the table is always denied.
+var routeRoles = map[string][]Role{
+	MainEndpoint:        {RoleReader},
+	InfoEndpoint:        {RoleReader},
+	SearchEndpoint:      {RoleReader},
+	RulesEndpoint:       {RoleReader},
+	RuleContentEndpoint: {RoleReader},
+
+	// admin operations are restricted to the content team
+	ContentReloadEndpoint: {RoleContentAdmin},
+
+	// debug endpoints are registered in debug mode only
+	DebugConfigEndpoint:              {RoleOperator},
+	DebugRoutesEndpoint:              {RoleOperator},
+	DebugPprofEndpoint:               {RoleOperator},
+	DebugPprofEndpoint + "cmdline":   {RoleOperator},
+	DebugPprofEndpoint + "profile":   {RoleOperator},
+	DebugPprofEndpoint + "symbol":    {RoleOperator},
+	DebugPprofEndpoint + "trace":     {RoleOperator},
+	DebugPprofEndpoint + "{profile}": {RoleOperator},
+}
+
+// Authorization middleware for checking that the current identity holds any
+// of the roles required by the requested endpoint
+func (server *HTTPServer) Authorization(next http.Handler, noAuthURLs []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// the same URLs as in Authentication middleware are not checked
+		if stringInSlice(r.RequestURI, noAuthURLs) || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, err := server.GetCurrentIdentity(r)
+		if err != nil {
+			server.rejectUnauthorized(w, r, AuditReasonMissingIdentity, Identity{}, err)
+			return
+		}
+
+		endpoint, found := server.endpointName(r)
+		if !found || !hasAnyRole(server.GetRoles(identity), routeRoles[endpoint]) {
+			const message = "Insufficient permissions"
+			server.rejectUnauthorized(w, r, AuditReasonInsufficientRoles, identity,
+				&AuthorizationError{errString: message})
+			return
+		}
+
+		server.auditAuthorization(r, AuditReasonRoleGranted, identity, nil)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetRoles returns all roles granted to given identity, either by the
+// configuration of its credentials (API key for example) or by role bindings
+// from configuration
+func (server *HTTPServer) GetRoles(identity Identity) []Role {
+	roles := []Role{RoleReader}
+	roles = append(roles, identity.Roles...)
+
+	for _, binding := range server.Config.RoleBindings {
+		if bindingMatches(binding, identity) {
+			roles = append(roles, binding.Role)
+		}
+	}
+
+	return roles
+}
+
+// endpointName returns path template of the matched route without API prefix
+func (server *HTTPServer) endpointName(r *http.Request) (string, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "", false
+	}
+
+	return strings.TrimPrefix(template, server.Config.APIPrefix), true
+}
+
+// adminHandler requires authentication and the roles from routeRoles for
+// debug and admin endpoints even when auth. of the other endpoints is
+// disabled
+func (server *HTTPServer) adminHandler(handler http.HandlerFunc) http.Handler {
+	if server.Config.Auth {
+		// auth. middlewares are already used for all routes
+		return handler
+	}
+
+	return server.Authentication(server.Authorization(handler, nil), nil)
+}
+
+func bindingMatches(binding RoleBinding, identity Identity) bool {
+	for _, orgID := range binding.OrgIDs {
+		if orgID == identity.Internal.OrgID {
+			return true
+		}
+	}
+
+	username := identity.User.Username
+	return username != "" && stringInSlice(username, binding.Usernames)
+}
+
+func hasAnyRole(granted []Role, allowed []Role) bool {
+	for _, role := range allowed {
+		for _, grantedRole := range granted {
+			if role == grantedRole {
+				return true
+			}
+		}
+	}
+	return false
+}
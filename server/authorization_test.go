@@ -0,0 +1,165 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+	"github.com/RedHatInsights/insights-content-service/types"
+)
+
+func roleBindingsConfig() server.Configuration {
+	cfg := authConfig()
+	cfg.RoleBindings = []server.RoleBinding{
+		{Role: server.RoleContentAdmin, Usernames: []string{"content-team-member"}},
+		{Role: server.RoleOperator, OrgIDs: []types.OrgID{42}},
+	}
+	return cfg
+}
+
+func TestGetRolesDefaultReader(t *testing.T) {
+	srv := server.New(roleBindingsConfig())
+
+	roles := srv.GetRoles(server.Identity{Internal: server.Internal{OrgID: 1}})
+
+	assert.Equal(t, []server.Role{server.RoleReader}, roles)
+}
+
+func TestGetRolesFromIdentity(t *testing.T) {
+	srv := server.New(roleBindingsConfig())
+
+	roles := srv.GetRoles(server.Identity{Roles: []server.Role{server.RoleContentAdmin}})
+
+	assert.ElementsMatch(t, []server.Role{server.RoleReader, server.RoleContentAdmin}, roles)
+}
+
+func TestGetRolesFromUsernameBinding(t *testing.T) {
+	srv := server.New(roleBindingsConfig())
+
+	roles := srv.GetRoles(server.Identity{User: server.User{Username: "content-team-member"}})
+
+	assert.ElementsMatch(t, []server.Role{server.RoleReader, server.RoleContentAdmin}, roles)
+}
+
+func TestGetRolesFromOrgBinding(t *testing.T) {
+	srv := server.New(roleBindingsConfig())
+
+	roles := srv.GetRoles(server.Identity{Internal: server.Internal{OrgID: 42}})
+
+	assert.ElementsMatch(t, []server.Role{server.RoleReader, server.RoleOperator}, roles)
+}
+
+func TestSelfAssertedRolesRejected(t *testing.T) {
+	testConfig := authConfig()
+	testConfig.Debug = true
+
+	// roles in the token are not verified by anyone, so they are ignored
+	token := base64.StdEncoding.EncodeToString([]byte(
+		`{"identity": {"account_number": "1", "internal": {"org_id": "1"}, "roles": ["operator", "content-admin"]}}`))
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.DebugRoutesEndpoint, nil)
+	req.Header.Set("x-rh-identity", token)
+
+	rr := executeRequest(server.New(testConfig), req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, `{"status": "Insufficient permissions"}`, rr.Body.String())
+}
+
+func TestSelfAssertedJWTRolesRejected(t *testing.T) {
+	testConfig := authConfig()
+	testConfig.AuthType = "jwt"
+	testConfig.Debug = true
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(
+		`{"account_number": "1", "org_id": "1", "roles": ["operator"]}`))
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.DebugRoutesEndpoint, nil)
+	req.Header.Set("Authorization", "Bearer e30."+payload+".c2ln")
+
+	rr := executeRequest(server.New(testConfig), req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+// serveWithIdentity routes the request through Authorization middleware with
+// identity already stored in the request context
+func serveWithIdentity(srv *server.HTTPServer, path string, identity server.Identity) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler { return srv.Authorization(next, nil) })
+	router.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req = req.WithContext(context.WithValue(req.Context(), server.ContextKeyUser, identity))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestAuthorizationReaderAllowed(t *testing.T) {
+	srv := server.New(roleBindingsConfig())
+
+	rr := serveWithIdentity(srv, config.APIPrefix+server.MainEndpoint, server.Identity{})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAuthorizationUnlistedEndpointDenied(t *testing.T) {
+	srv := server.New(roleBindingsConfig())
+
+	rr := serveWithIdentity(srv, config.APIPrefix+"not-in-table", server.Identity{
+		Roles: []server.Role{server.RoleContentAdmin, server.RoleOperator},
+	})
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, `{"status": "Insufficient permissions"}`, rr.Body.String())
+}
+
+func TestAuthorizationMissingIdentity(t *testing.T) {
+	srv := server.New(roleBindingsConfig())
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler { return srv.Authorization(next, nil) })
+	router.HandleFunc(config.APIPrefix, func(w http.ResponseWriter, _ *http.Request) {})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestCertificateRoleBindingsNotGrantedToTokens(t *testing.T) {
+	testConfig := authConfig()
+	testConfig.RoleBindings = []server.RoleBinding{
+		{Role: server.RoleOperator, Certificates: []string{"smart-proxy"}},
+	}
+
+	for _, username := range []string{"smart-proxy", server.CertificateUsernamePrefix + "smart-proxy"} {
+		identity := server.Identity{Type: server.ServiceIdentityType, User: server.User{Username: username}}
+
+		assert.Equal(t, []server.Role{server.RoleReader}, server.New(testConfig).GetRoles(identity))
+	}
+}
@@ -0,0 +1,343 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings supported by the server
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// defaultCompressionMinSize is used when minimal size of compressed
+// responses is not configured
+const defaultCompressionMinSize = 1024
+
+// supportedEncodings are listed in order of preference
+var supportedEncodings = []string{EncodingZstd, EncodingGzip}
+
+// encoder is implemented by both gzip and zstd writers
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// encoderPools keep encoders for reuse, creating them is expensive
+var encoderPools = map[string]*sync.Pool{
+	EncodingGzip: {New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}},
+	EncodingZstd: {New: func() interface{} {
+		// the options are valid, so no error can happen
+		zstdEncoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return zstdEncoder
+	}},
+}
+
+// getEncoder returns encoder of given content encoding writing to writer,
+// the encoder has to be returned by putEncoder after it is closed
+func getEncoder(encoding string, writer io.Writer) encoder {
+	enc := encoderPools[encoding].Get().(encoder)
+	enc.Reset(writer)
+	return enc
+}
+
+func putEncoder(encoding string, enc encoder) {
+	encoderPools[encoding].Put(enc)
+}
+
+// negotiateEncoding returns the preferred supported encoding accepted by
+// the caller according to Accept-Encoding header, it is empty when the
+// response must not be compressed
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				quality, err = strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err != nil {
+					quality = 0
+				}
+			}
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range supportedEncodings {
+		quality, found := qualities[encoding]
+		if !found {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+
+	return best
+}
+
+// compressionMinSize returns configured minimal size of compressed responses
+func (server *HTTPServer) compressionMinSize() int {
+	if server.Config.Compression.MinSize > 0 {
+		return server.Config.Compression.MinSize
+	}
+	return defaultCompressionMinSize
+}
+
+// Compression middleware compresses responses by encoding negotiated by
+// Accept-Encoding header. Responses smaller than configured minimal size and
+// responses already encoded by the endpoint are sent as they are.
+func (server *HTTPServer) Compression(next http.Handler) http.Handler {
+	minSize := server.compressionMinSize()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addVary(w.Header(), "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+		defer writer.close()
+
+		next.ServeHTTP(writer, r)
+	})
+}
+
+// modes of compressWriter
+const (
+	compressUndecided = iota
+	compressPassThrough
+	compressEncoding
+)
+
+// compressWriter buffers beginning of the response until it is clear that
+// the response is large enough to be compressed
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	mode     int
+	status   int
+	buffer   []byte
+	encoder  encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.mode != compressUndecided || w.status != 0 {
+		return
+	}
+	w.status = status
+
+	// responses without body, responses encoded by the endpoint and
+	// partial responses, whose ranges refer to the unencoded body
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified ||
+		status == http.StatusPartialContent || w.Header().Get("Content-Range") != "" ||
+		w.Header().Get("Content-Encoding") != "" {
+		w.passThrough()
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	switch w.mode {
+	case compressPassThrough:
+		return w.ResponseWriter.Write(b)
+	case compressEncoding:
+		return w.encoder.Write(b)
+	}
+
+	w.buffer = append(w.buffer, b...)
+	if len(w.buffer) >= w.minSize {
+		if err := w.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Flush sends buffered data, the response is compressed when it has not
+// been decided yet
+func (w *compressWriter) Flush() {
+	if w.mode == compressUndecided && len(w.buffer) > 0 {
+		_ = w.startEncoding()
+	}
+	if w.mode == compressEncoding {
+		_ = w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) passThrough() {
+	w.mode = compressPassThrough
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *compressWriter) startEncoding() error {
+	w.mode = compressEncoding
+
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.encoder = getEncoder(w.encoding, w.ResponseWriter)
+	_, err := w.encoder.Write(w.buffer)
+	w.buffer = nil
+
+	return err
+}
+
+// close finishes the response, small responses are sent uncompressed
+func (w *compressWriter) close() {
+	switch w.mode {
+	case compressEncoding:
+		_ = w.encoder.Close()
+		putEncoder(w.encoding, w.encoder)
+	case compressUndecided:
+		if w.status == 0 {
+			// nothing has been written by the endpoint
+			return
+		}
+		w.passThrough()
+		_, _ = w.ResponseWriter.Write(w.buffer)
+	}
+}
+
+// bundleCache keeps bodies of large responses, both uncompressed and
+// compressed, for the currently served content
+type bundleCache struct {
+	mutex   sync.Mutex
+	content string
+	bodies  map[string][]byte
+}
+
+// get returns body stored under given key and encoding, the body is built
+// and compressed when it is not in the cache. The cache is cleared when
+// different content is served.
+func (cache *bundleCache) get(content, key, encoding string, build func() ([]byte, error)) ([]byte, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.bodies == nil || cache.content != content {
+		cache.content = content
+		cache.bodies = make(map[string][]byte)
+	}
+
+	cacheKey := key + "\x00" + encoding
+	if body, found := cache.bodies[cacheKey]; found {
+		return body, nil
+	}
+
+	body, found := cache.bodies[key+"\x00"]
+	if !found {
+		var err error
+		body, err = build()
+		if err != nil {
+			return nil, err
+		}
+		cache.bodies[key+"\x00"] = body
+	}
+
+	if encoding == "" {
+		return body, nil
+	}
+
+	var compressed bytes.Buffer
+	enc := getEncoder(encoding, &compressed)
+	_, err := enc.Write(body)
+	if err == nil {
+		err = enc.Close()
+	}
+	putEncoder(encoding, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.bodies[cacheKey] = compressed.Bytes()
+	return cache.bodies[cacheKey], nil
+}
+
+// SendBundle sends large response that stays the same as long as the served
+// content does not change, the full content bundle for example. The body is
+// built by given function and compressed only once for each content version
+// and encoding.
+func (server *HTTPServer) SendBundle(w http.ResponseWriter, r *http.Request, key, contentType string, build func() ([]byte, error)) {
+	contentKey := server.ContentStatus().key()
+
+	body, err := server.bundles.get(contentKey, key, "", build)
+	if err != nil {
+		handleServerError(w, r, err)
+		return
+	}
+
+	encoding := ""
+	if server.Config.Compression.Enabled && len(body) >= server.compressionMinSize() {
+		encoding = negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	}
+
+	if encoding != "" {
+		body, err = server.bundles.get(contentKey, key, encoding, build)
+		if err != nil {
+			handleServerError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Encoding", encoding)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method != http.MethodHead {
+		_, err = w.Write(body)
+		if err != nil {
+			requestLogger(r).Error().Err(err).Msg(responseDataError)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+var largeBody = strings.Repeat(`{"rule_id": "ccx_rules_ocp.external.rules.nodes_kubelet_version_check"}`, 100)
+
+func compressionConfig(minSize int) server.Configuration {
+	compressionConfig := config
+	compressionConfig.Compression = server.CompressionConfiguration{Enabled: true, MinSize: minSize}
+	return compressionConfig
+}
+
+// serveCompressed serves request by handler writing given body wrapped by
+// Compression middleware
+func serveCompressed(testServer *server.HTTPServer, acceptEncoding, body string) *httptest.ResponseRecorder {
+	handler := testServer.Compression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+// decompress decodes body compressed by given encoding
+func decompress(t *testing.T, encoding string, body []byte) string {
+	switch encoding {
+	case server.EncodingGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decompressed, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err)
+		return string(decompressed)
+	case server.EncodingZstd:
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer decoder.Close()
+		decompressed, err := decoder.DecodeAll(body, nil)
+		assert.NoError(t, err)
+		return string(decompressed)
+	default:
+		return string(body)
+	}
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	testServer := server.New(compressionConfig(0))
+
+	for acceptEncoding, expected := range map[string]string{
+		"gzip":                    server.EncodingGzip,
+		"gzip, deflate, br, zstd": server.EncodingZstd,
+		"zstd;q=0.5, gzip":        server.EncodingGzip,
+		"zstd;q=0, gzip;q=0.1":    server.EncodingGzip,
+		"*":                       server.EncodingZstd,
+		"*, zstd;q=0":             server.EncodingGzip,
+		"br, deflate":             "",
+		"":                        "",
+	} {
+		rr := serveCompressed(testServer, acceptEncoding, largeBody)
+
+		assert.Equal(t, expected, rr.Header().Get("Content-Encoding"), acceptEncoding)
+		assert.Equal(t, largeBody, decompress(t, expected, rr.Body.Bytes()), acceptEncoding)
+		assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+	}
+}
+
+func TestCompressionMinSize(t *testing.T) {
+	testServer := server.New(compressionConfig(len(largeBody) + 1))
+
+	rr := serveCompressed(testServer, "gzip", largeBody)
+
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, largeBody, rr.Body.String())
+}
+
+func TestCompressionDefaultMinSize(t *testing.T) {
+	testServer := server.New(compressionConfig(0))
+
+	rr := serveCompressed(testServer, "gzip", `{"status": "ok"}`)
+
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"status": "ok"}`, rr.Body.String())
+}
+
+func TestCompressionInRouter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.MetricsEndpoint, nil)
+	req.Header.Set("Accept-Encoding", "zstd")
+
+	rr := executeRequest(server.New(compressionConfig(0)), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, server.EncodingZstd, rr.Header().Get("Content-Encoding"))
+	assert.Contains(t, decompress(t, server.EncodingZstd, rr.Body.Bytes()), "api_endpoints_requests_in_flight")
+}
+
+func TestCompressionSkipsPartialContent(t *testing.T) {
+	handler := server.New(compressionConfig(0)).Compression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "openapi.json", time.Time{}, strings.NewReader(largeBody))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+"openapi.json", nil)
+	req.Header.Set("Accept-Encoding", server.EncodingGzip)
+	req.Header.Set("Range", "bytes=0-99")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPartialContent, rr.Code)
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, "bytes 0-99/"+strconv.Itoa(len(largeBody)), rr.Header().Get("Content-Range"))
+	assert.Equal(t, largeBody[:100], rr.Body.String())
+}
+
+func TestSendBundleCache(t *testing.T) {
+	testServer := server.New(compressionConfig(0))
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	builds := 0
+	handler := testServer.Compression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testServer.SendBundle(w, r, "content", "application/json", func() ([]byte, error) {
+			builds++
+			return []byte(largeBody), nil
+		})
+	}))
+
+	serve := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, config.APIPrefix+"content", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 3; i++ {
+		for _, encoding := range []string{server.EncodingGzip, server.EncodingZstd, ""} {
+			rr := serve(encoding)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, encoding, rr.Header().Get("Content-Encoding"))
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			assert.Equal(t, largeBody, decompress(t, encoding, rr.Body.Bytes()))
+		}
+	}
+	assert.Equal(t, 1, builds)
+
+	// new content version invalidates the cache
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+	serve(server.EncodingGzip)
+	assert.Equal(t, 2, builds)
+}
+
+func TestSendBundleBuildError(t *testing.T) {
+	testServer := server.New(compressionConfig(0))
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+"content", nil)
+	rr := httptest.NewRecorder()
+	testServer.SendBundle(rr, req, "content", "application/json", func() ([]byte, error) {
+		return nil, assert.AnError
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
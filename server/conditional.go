@@ -0,0 +1,180 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ConditionalContent middleware adds strong ETag and Last-Modified headers
+// to successful responses of content endpoints and answers conditional
+// requests for unchanged content with 304 Not Modified. Validators are added
+// only once the endpoint has accepted the request, so requests rejected by
+// the endpoint (invalid query, unsupported format) are never answered with
+// 304 and their error responses carry no ETag.
+// The ETag is computed from the content version and the response variant,
+// which is given by the path, query and content negotiation headers, so the
+// negotiation headers are listed in Vary header.
+func (server *HTTPServer) ConditionalContent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := server.ContentStatus()
+
+		// nothing is cached before content is loaded and for
+		// non-idempotent requests
+		if content.LoadedAt == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		addVary(w.Header(), "Accept", "Accept-Encoding")
+
+		next.ServeHTTP(&conditionalWriter{
+			ResponseWriter: w,
+			request:        r,
+			etag:           contentETag(content, r),
+			lastModified:   content.LoadedAt.UTC().Truncate(time.Second),
+		}, r)
+	})
+}
+
+// conditionalWriter adds validators to successful responses and replaces
+// them with 304 Not Modified when the preconditions of request say so
+type conditionalWriter struct {
+	http.ResponseWriter
+	request      *http.Request
+	etag         string
+	lastModified time.Time
+	wroteHeader  bool
+	notModified  bool
+}
+
+func (w *conditionalWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if status < http.StatusOK || status >= http.StatusMultipleChoices {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	header := w.Header()
+	header.Set("ETag", w.etag)
+	header.Set("Last-Modified", w.lastModified.Format(http.TimeFormat))
+
+	if isNotModified(w.request, w.etag, w.lastModified) {
+		w.notModified = true
+		header.Del("Content-Length")
+		w.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *conditionalWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	// body of unchanged content is not sent
+	if w.notModified {
+		return len(b), nil
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client
+func (w *conditionalWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// contentETag computes strong ETag of response variant for given content
+func contentETag(content ContentStatus, r *http.Request) string {
+	hash := sha256.New()
+
+	for _, part := range []string{
+		content.Version,
+		content.Revision,
+		content.LoadedAt.UTC().Format(time.RFC3339Nano),
+		r.URL.Path,
+		// encoding sorts the parameters
+		r.URL.Query().Encode(),
+		r.Header.Get("Accept"),
+		r.Header.Get("Accept-Encoding"),
+	} {
+		_, _ = hash.Write([]byte(part))
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// isNotModified evaluates If-None-Match or, when it is not present,
+// If-Modified-Since precondition
+func isNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.After(ifModifiedSince)
+}
+
+// etagMatches uses weak comparison required for If-None-Match header
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary adds fields to Vary header unless they are listed there already
+func addVary(header http.Header, fields ...string) {
+	listed := []string{}
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			listed = append(listed, strings.TrimSpace(field))
+		}
+	}
+
+	missing := []string{}
+	for _, field := range fields {
+		if !headerInSlice(field, listed) {
+			missing = append(missing, field)
+		}
+	}
+
+	if len(missing) > 0 {
+		header.Add("Vary", strings.Join(missing, ", "))
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/metrics"
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+// conditionalHandler wraps handler counting its calls by ConditionalContent middleware
+func conditionalHandler(testServer *server.HTTPServer, calls *int) http.Handler {
+	return testServer.ConditionalContent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		_, _ = w.Write([]byte(`{"rules": []}`))
+	}))
+}
+
+func serveConditional(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestConditionalContentHeaders(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	calls := 0
+	rr := serveConditional(conditionalHandler(testServer, &calls), httptest.NewRequest(http.MethodGet, "/rules", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, calls)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, rr.Header().Get("ETag"))
+
+	lastModified, err := http.ParseTime(rr.Header().Get("Last-Modified"))
+	assert.NoError(t, err)
+	assert.WithinDuration(t, *testServer.ContentStatus().LoadedAt, lastModified, time.Second)
+}
+
+func TestConditionalContentIfNoneMatch(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	calls := 0
+	handler := conditionalHandler(testServer, &calls)
+	etag := serveConditional(handler, httptest.NewRequest(http.MethodGet, "/rules", nil)).Header().Get("ETag")
+
+	req := httptest.NewRequest(http.MethodGet, "/rules", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	rr := serveConditional(handler, req)
+
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, etag, rr.Header().Get("ETag"))
+	// endpoint is called to validate the request, its body is dropped
+	assert.Equal(t, 2, calls)
+
+	// new content version changes the ETag
+	newContent := loadedContent
+	newContent.Version = "1.2.4"
+	testServer.RecordContentLoad(newContent, time.Second, nil)
+
+	rr = serveConditional(handler, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+	assert.Equal(t, 3, calls)
+}
+
+func TestConditionalContentVariants(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	calls := 0
+	handler := conditionalHandler(testServer, &calls)
+
+	etag := func(target, accept string) string {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", accept)
+		return serveConditional(handler, req).Header().Get("ETag")
+	}
+
+	assert.Equal(t, etag("/rules?a=1&b=2", "application/json"), etag("/rules?b=2&a=1", "application/json"))
+	assert.NotEqual(t, etag("/rules", "application/json"), etag("/rules", "application/yaml"))
+	assert.NotEqual(t, etag("/rules", "application/json"), etag("/tags", "application/json"))
+	assert.NotEqual(t, etag("/rules?limit=1", "application/json"), etag("/rules?limit=2", "application/json"))
+}
+
+func TestConditionalContentIfModifiedSince(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	calls := 0
+	handler := conditionalHandler(testServer, &calls)
+
+	req := httptest.NewRequest(http.MethodGet, "/rules", nil)
+	req.Header.Set("If-Modified-Since", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.Equal(t, http.StatusNotModified, serveConditional(handler, req).Code)
+
+	req.Header.Set("If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	assert.Equal(t, http.StatusOK, serveConditional(handler, req).Code)
+
+	// If-None-Match takes precedence
+	req.Header.Set("If-Modified-Since", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	req.Header.Set("If-None-Match", `"other"`)
+	assert.Equal(t, http.StatusOK, serveConditional(handler, req).Code)
+}
+
+func TestConditionalContentNotLoaded(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(metrics.ContentLoadStats{}, time.Second, assert.AnError)
+
+	calls := 0
+	req := httptest.NewRequest(http.MethodGet, "/rules", nil)
+	req.Header.Set("If-None-Match", "*")
+
+	rr := serveConditional(conditionalHandler(testServer, &calls), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("ETag"))
+	assert.Empty(t, rr.Header().Get("Last-Modified"))
+}
+
+func TestConditionalContentPost(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	calls := 0
+	req := httptest.NewRequest(http.MethodPost, "/rules/content", nil)
+	req.Header.Set("If-None-Match", "*")
+
+	rr := serveConditional(conditionalHandler(testServer, &calls), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("ETag"))
+}
+
+func TestConditionalSearchEndpoint(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.SearchEndpoint+"?q=etcd", nil)
+	rr := executeRequest(testServer, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"Accept, Accept-Encoding"}, rr.Header().Values("Vary"))
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req.Header.Set("If-None-Match", etag)
+	rr = executeRequest(testServer, req)
+
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, []string{"Accept, Accept-Encoding"}, rr.Header().Values("Vary"))
+}
+
+func TestConditionalContentRejectedRequests(t *testing.T) {
+	testServer := rulesServer()
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+
+	for _, tc := range []struct {
+		query           string
+		ifModifiedSince string
+		status          int
+	}{
+		{"?limit=abc", "", http.StatusBadRequest},
+		{"?limit=abc", future, http.StatusBadRequest},
+		{"?format=xml", "", http.StatusNotAcceptable},
+		{"?format=xml", future, http.StatusNotAcceptable},
+	} {
+		req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.RulesEndpoint+tc.query, nil)
+		if tc.ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", tc.ifModifiedSince)
+		}
+
+		rr := executeRequest(testServer, req)
+
+		assert.Equal(t, tc.status, rr.Code, tc.query)
+		assert.Empty(t, rr.Header().Get("ETag"), tc.query)
+		assert.Empty(t, rr.Header().Get("Last-Modified"), tc.query)
+		assert.NotEmpty(t, rr.Body.String(), tc.query)
+	}
+}
+
+func TestConditionalContentVary(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	calls := 0
+	req := httptest.NewRequest(http.MethodGet, "/rules", nil)
+	rr := httptest.NewRecorder()
+	rr.Header().Set("Vary", "Accept-Encoding")
+	conditionalHandler(testServer, &calls).ServeHTTP(rr, req)
+
+	// fields already listed are not repeated
+	assert.Equal(t, []string{"Accept-Encoding", "Accept"}, rr.Header().Values("Vary"))
+}
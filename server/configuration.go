@@ -16,12 +16,143 @@ limitations under the License.
 
 package server
 
+import (
+	"time"
+
+	"github.com/RedHatInsights/insights-content-service/types"
+)
+
 // Configuration represents configuration of REST API HTTP server
 type Configuration struct {
-	Address     string `mapstructure:"address" toml:"address"`
-	APIPrefix   string `mapstructure:"api_prefix" toml:"api_prefix"`
-	APISpecFile string `mapstructure:"api_spec_file" toml:"api_spec_file"`
-	Debug       bool   `mapstructure:"debug" toml:"debug"`
-	Auth        bool   `mapstructure:"auth" toml:"auth"`
-	AuthType    string `mapstructure:"auth_type" toml:"auth_type"`
+	// Address is either TCP address (host:port) or path of Unix domain
+	// socket prefixed by unix://, it is ignored when the service is
+	// started by systemd socket activation
+	Address      string                   `mapstructure:"address" toml:"address"`
+	APIPrefix    string                   `mapstructure:"api_prefix" toml:"api_prefix"`
+	APISpecFile  string                   `mapstructure:"api_spec_file" toml:"api_spec_file"`
+	Debug        bool                     `mapstructure:"debug" toml:"debug"`
+	Auth         bool                     `mapstructure:"auth" toml:"auth"`
+	AuthType     string                   `mapstructure:"auth_type" toml:"auth_type"`
+	RoleBindings []RoleBinding            `mapstructure:"role_bindings" toml:"role_bindings"`
+	APIKeysFile  string                   `mapstructure:"api_keys_file" toml:"api_keys_file"`
+	AuditLogFile string                   `mapstructure:"audit_log_file" toml:"audit_log_file"`
+	RateLimit    RateLimitConfiguration   `mapstructure:"rate_limit" toml:"rate_limit"`
+	AccessLog    AccessLogConfiguration   `mapstructure:"access_log" toml:"access_log"`
+	Faults       []FaultConfiguration     `mapstructure:"faults" toml:"faults"`
+	TLS          TLSConfiguration         `mapstructure:"tls" toml:"tls"`
+	CORS         CORSConfiguration        `mapstructure:"cors" toml:"cors"`
+	Compression  CompressionConfiguration `mapstructure:"compression" toml:"compression"`
+	// DrainPeriod is time between readiness probe starts failing and the
+	// server stops accepting new connections during shutdown
+	DrainPeriod time.Duration `mapstructure:"drain_period" toml:"drain_period"`
+	// ShutdownTimeout limits time the in-flight requests can take during
+	// shutdown, 30 seconds is used when not set
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// RoleBinding grants a role to all identities from the listed organizations,
+// to the listed users and to the services authenticated by client
+// certificates with the listed common names
+type RoleBinding struct {
+	Role         Role          `mapstructure:"role" toml:"role"`
+	OrgIDs       []types.OrgID `mapstructure:"org_ids" toml:"org_ids"`
+	Usernames    []string      `mapstructure:"usernames" toml:"usernames"`
+	Certificates []string      `mapstructure:"certificates" toml:"certificates"`
+}
+
+// RateLimitConfiguration represents configuration of per-organization rate
+// limiting. The default limit applies to every organization (or remote IP
+// for unauthenticated requests) that has no limit of its own. Burst is the
+// number of requests per second (at least one) when it is not set.
+type RateLimitConfiguration struct {
+	Enabled           bool           `mapstructure:"enabled" toml:"enabled"`
+	RequestsPerSecond float64        `mapstructure:"requests_per_second" toml:"requests_per_second"`
+	Burst             int            `mapstructure:"burst" toml:"burst"`
+	Orgs              []OrgRateLimit `mapstructure:"orgs" toml:"orgs"`
+}
+
+// OrgRateLimit overrides the default rate limit for one organization
+type OrgRateLimit struct {
+	OrgID             types.OrgID `mapstructure:"org_id" toml:"org_id"`
+	RequestsPerSecond float64     `mapstructure:"requests_per_second" toml:"requests_per_second"`
+	Burst             int         `mapstructure:"burst" toml:"burst"`
+}
+
+// AccessLogConfiguration represents configuration of access log
+type AccessLogConfiguration struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled"`
+	// Format is either "json" (default) or "combined" (Apache combined log format)
+	Format string `mapstructure:"format" toml:"format"`
+	// Output is either "stdout" (default) or path to log file
+	Output string `mapstructure:"output" toml:"output"`
+	// MaxSize is size of log file in megabytes that triggers rotation
+	MaxSize int `mapstructure:"max_size" toml:"max_size"`
+	// MaxBackups is number of rotated log files to keep
+	MaxBackups int `mapstructure:"max_backups" toml:"max_backups"`
+	// MaxAge is number of days to keep rotated log files
+	MaxAge int `mapstructure:"max_age" toml:"max_age"`
+	// SkipPaths lists request paths that are not logged, health probes for example
+	SkipPaths []string `mapstructure:"skip_paths" toml:"skip_paths"`
+}
+
+// TLSConfiguration represents configuration of TLS and verification of
+// client certificates. Certificate and key files are reloaded automatically
+// when they change.
+type TLSConfiguration struct {
+	Enabled  bool   `mapstructure:"enabled" toml:"enabled"`
+	CertFile string `mapstructure:"cert_file" toml:"cert_file"`
+	KeyFile  string `mapstructure:"key_file" toml:"key_file"`
+	// MinVersion is either "1.2" (default) or "1.3"
+	MinVersion string `mapstructure:"min_version" toml:"min_version"`
+	// CipherPolicy is either "intermediate" (default, AEAD cipher suites
+	// with forward secrecy) or "modern" (TLS 1.3 only)
+	CipherPolicy string `mapstructure:"cipher_policy" toml:"cipher_policy"`
+	// ClientCAFile is CA bundle client certificates are verified against,
+	// client certificates are not requested when it is not set
+	ClientCAFile string `mapstructure:"client_ca_file" toml:"client_ca_file"`
+	// RequireClientCert rejects connections without a valid client certificate
+	RequireClientCert bool `mapstructure:"require_client_cert" toml:"require_client_cert"`
+	// ReloadCheckInterval is the minimal time between checks whether the
+	// certificate files have changed, 10 seconds is used when not set
+	ReloadCheckInterval time.Duration `mapstructure:"reload_check_interval" toml:"reload_check_interval"`
+}
+
+// CORSConfiguration represents CORS policy of the REST API
+type CORSConfiguration struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled"`
+	// AllowedOrigins lists origins allowed to call the API. Origin
+	// https://*.example.com allows all subdomains of example.com and *
+	// allows any origin.
+	AllowedOrigins []string `mapstructure:"allowed_origins" toml:"allowed_origins"`
+	// AllowedMethods are GET and HEAD when not set
+	AllowedMethods []string `mapstructure:"allowed_methods" toml:"allowed_methods"`
+	// AllowedHeaders lists request headers the callers can send, headers
+	// used for authentication and X-Request-ID are allowed when not set
+	AllowedHeaders []string `mapstructure:"allowed_headers" toml:"allowed_headers"`
+	// AllowCredentials allows the browsers to send cookies and TLS client
+	// certificates, it can not be combined with * origin
+	AllowCredentials bool `mapstructure:"allow_credentials" toml:"allow_credentials"`
+	// MaxAge is the time browsers can cache results of preflight requests
+	MaxAge time.Duration `mapstructure:"max_age" toml:"max_age"`
+}
+
+// CompressionConfiguration represents configuration of response compression
+type CompressionConfiguration struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled"`
+	// MinSize is the size of response body in bytes below which the
+	// response is not compressed, 1024 bytes are used when not set
+	MinSize int `mapstructure:"min_size" toml:"min_size"`
+}
+
+// FaultConfiguration describes fault injected into responses of one
+// endpoint, faults are injected only in debug mode
+type FaultConfiguration struct {
+	// Endpoint is the path of the endpoint without API prefix
+	Endpoint string `mapstructure:"endpoint" toml:"endpoint"`
+	// Latency is added to every response of the endpoint
+	Latency time.Duration `mapstructure:"latency" toml:"latency"`
+	// ErrorRate is the ratio of requests (between 0 and 1) that fail
+	ErrorRate float64 `mapstructure:"error_rate" toml:"error_rate"`
+	// StatusCode of the failed requests, 500 is used when not set
+	StatusCode int `mapstructure:"status_code" toml:"status_code"`
 }
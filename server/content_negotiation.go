@@ -0,0 +1,95 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/RedHatInsights/insights-content-service/formats"
+)
+
+const (
+	// FormatParameter is the name of query parameter overriding format
+	// negotiated by Accept header
+	FormatParameter = "format"
+	// PrettyParameter is the name of query parameter that turns on
+	// indentation of JSON responses
+	PrettyParameter = "pretty"
+)
+
+// NotAcceptableError happens when the content can not be sent in any format
+// accepted by caller
+type NotAcceptableError struct {
+	errString string
+}
+
+func (e *NotAcceptableError) Error() string {
+	return e.errString
+}
+
+// responseFormat returns format the response should be sent in, format
+// query parameter takes precedence over Accept header
+func responseFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get(FormatParameter); format != "" {
+		if !formats.IsSupported(format) {
+			return "", &NotAcceptableError{errString: "Unsupported format '" + format + "'"}
+		}
+		return format, nil
+	}
+
+	format, found := formats.Negotiate(r.Header.Get("Accept"))
+	if !found {
+		return "", &NotAcceptableError{errString: "None of the accepted media types is supported"}
+	}
+	return format, nil
+}
+
+// SendContent sends value in format negotiated by Accept header or selected
+// by format query parameter. JSON is sent when caller does not express any
+// preference and it is indented when pretty query parameter is true.
+func (server *HTTPServer) SendContent(w http.ResponseWriter, r *http.Request, value interface{}) {
+	addVary(w.Header(), "Accept")
+
+	format, err := responseFormat(r)
+	if err != nil {
+		handleServerError(w, r, err)
+		return
+	}
+
+	pretty, _ := strconv.ParseBool(r.URL.Query().Get(PrettyParameter))
+
+	// the value is encoded into buffer first so the encoding errors can
+	// still be reported by status code
+	var body bytes.Buffer
+	err = formats.Encode(format, &body, value, pretty)
+	if err != nil {
+		handleServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", formats.MediaType(format))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method != http.MethodHead {
+		_, err = w.Write(body.Bytes())
+		if err != nil {
+			requestLogger(r).Error().Err(err).Msg(responseDataError)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/formats"
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+type contentRule struct {
+	RuleID string `json:"rule_id"`
+	Impact int    `json:"impact"`
+}
+
+var negotiatedContent = contentRule{RuleID: "nodes_kubelet_version_check", Impact: 2}
+
+// sendContent sends negotiatedContent as response to request with given
+// query and Accept header
+func sendContent(method, query, accept string) *httptest.ResponseRecorder {
+	testServer := server.New(config)
+
+	req := httptest.NewRequest(method, config.APIPrefix+query, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	rr := httptest.NewRecorder()
+	testServer.SendContent(rr, req, negotiatedContent)
+	return rr
+}
+
+func TestSendContentNegotiation(t *testing.T) {
+	for accept, format := range map[string]string{
+		"":                      formats.JSON,
+		"*/*":                   formats.JSON,
+		"application/yaml":      formats.YAML,
+		"application/x-gob":     formats.Gob,
+		"application/x-msgpack": formats.MessagePack,
+	} {
+		rr := sendContent(http.MethodGet, "", accept)
+
+		assert.Equal(t, http.StatusOK, rr.Code, accept)
+		assert.Equal(t, formats.MediaType(format), rr.Header().Get("Content-Type"), accept)
+		assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+
+		var decoded contentRule
+		assert.NoError(t, formats.Decode(format, rr.Body, &decoded), accept)
+		assert.Equal(t, negotiatedContent, decoded, accept)
+	}
+}
+
+func TestSendContentFormatParameter(t *testing.T) {
+	rr := sendContent(http.MethodGet, "?format=yaml", "application/json")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/yaml", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "impact: 2\nrule_id: nodes_kubelet_version_check\n", rr.Body.String())
+}
+
+func TestSendContentPretty(t *testing.T) {
+	rr := sendContent(http.MethodGet, "?pretty=true", "")
+	assert.Equal(t, "{\n  \"rule_id\": \"nodes_kubelet_version_check\",\n  \"impact\": 2\n}\n", rr.Body.String())
+
+	rr = sendContent(http.MethodGet, "?pretty=false", "")
+	assert.Equal(t, "{\"rule_id\":\"nodes_kubelet_version_check\",\"impact\":2}\n", rr.Body.String())
+}
+
+func TestSendContentNotAcceptable(t *testing.T) {
+	rr := sendContent(http.MethodGet, "", "text/html")
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+
+	rr = sendContent(http.MethodGet, "?format=xml", "")
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Unsupported format 'xml'")
+}
+
+func TestSendContentHead(t *testing.T) {
+	rr := sendContent(http.MethodHead, "", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Empty(t, rr.Body.String())
+}
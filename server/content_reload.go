@@ -0,0 +1,50 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+)
+
+const contentReloadNotSupportedMessage = "Content reload is not supported"
+
+// contentReloadEndpoint forces reload of the served content, it is available
+// to the content team only
+func (server *HTTPServer) contentReloadEndpoint(writer http.ResponseWriter, request *http.Request) {
+	if server.ContentReload == nil {
+		err := responses.Send(http.StatusNotImplemented, writer, contentReloadNotSupportedMessage)
+		if err != nil {
+			requestLogger(request).Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	requestLogger(request).Info().Msg("Content reload requested")
+
+	err := server.ContentReload()
+	if err != nil {
+		handleServerError(writer, request, err)
+		return
+	}
+
+	err = responses.SendResponse(writer, responses.BuildOkResponse())
+	if err != nil {
+		requestLogger(request).Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+	"github.com/RedHatInsights/insights-content-service/types"
+)
+
+// contentAdminOrgID is the organization bound to content-admin role by
+// contentReloadConfig
+const contentAdminOrgID = types.OrgID(7)
+
+func contentReloadConfig(auth bool) server.Configuration {
+	testConfig := authConfig()
+	testConfig.Auth = auth
+	testConfig.RoleBindings = []server.RoleBinding{
+		{Role: server.RoleContentAdmin, OrgIDs: []types.OrgID{contentAdminOrgID}},
+		{Role: server.RoleOperator, OrgIDs: []types.OrgID{42}},
+	}
+	return testConfig
+}
+
+func contentReloadRequest(t *testing.T, orgID types.OrgID) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, config.APIPrefix+server.ContentReloadEndpoint, nil)
+	if orgID != 0 {
+		req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{
+			AccountNumber: "1",
+			Internal:      server.Internal{OrgID: orgID},
+		}))
+	}
+	return req
+}
+
+func TestContentReloadEndpoint(t *testing.T) {
+	reloads := 0
+	testServer := server.New(contentReloadConfig(true))
+	testServer.ContentReload = func() error {
+		reloads++
+		return nil
+	}
+
+	rr := executeRequest(testServer, contentReloadRequest(t, contentAdminOrgID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, rr.Body.String())
+	assert.Equal(t, 1, reloads)
+}
+
+func TestContentReloadRequiresContentAdminRole(t *testing.T) {
+	for _, auth := range []bool{true, false} {
+		reloads := 0
+		testServer := server.New(contentReloadConfig(auth))
+		testServer.ContentReload = func() error {
+			reloads++
+			return nil
+		}
+
+		// readers and operators are not allowed to reload content
+		for _, orgID := range []types.OrgID{0, 1, 42} {
+			rr := executeRequest(testServer, contentReloadRequest(t, orgID))
+			assert.Equal(t, http.StatusForbidden, rr.Code, orgID)
+		}
+
+		rr := executeRequest(testServer, contentReloadRequest(t, contentAdminOrgID))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, 1, reloads)
+	}
+}
+
+func TestContentReloadError(t *testing.T) {
+	testServer := server.New(contentReloadConfig(true))
+	testServer.ContentReload = func() error {
+		return assert.AnError
+	}
+
+	rr := executeRequest(testServer, contentReloadRequest(t, contentAdminOrgID))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestContentReloadNotSupported(t *testing.T) {
+	rr := executeRequest(server.New(contentReloadConfig(true)), contentReloadRequest(t, contentAdminOrgID))
+
+	assert.Equal(t, http.StatusNotImplemented, rr.Code)
+	assert.JSONEq(t, `{"status": "Content reload is not supported"}`, rr.Body.String())
+}
@@ -0,0 +1,181 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodHead}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "x-rh-identity", APIKeyHeader, RequestIDHeader}
+
+	// corsExposedHeaders are response headers readable by scripts in
+	// addition to the CORS-safelisted ones
+	corsExposedHeaders = []string{RequestIDHeader, "ETag", "Retry-After"}
+)
+
+// errCORSWildcardWithCredentials is returned for CORS configuration that
+// allows any origin to send credentials
+var errCORSWildcardWithCredentials = errors.New(
+	"CORS origin '*' can not be allowed together with credentials, list the allowed origins instead")
+
+// validateCORSConfiguration rejects CORS policy the browsers would not
+// accept or that would be unsafe
+func validateCORSConfiguration(config CORSConfiguration) error {
+	if config.AllowCredentials && stringInSlice("*", config.AllowedOrigins) {
+		return errCORSWildcardWithCredentials
+	}
+	return nil
+}
+
+// CORS middleware answers preflight requests and adds CORS headers to
+// responses for allowed origins. It wraps the whole router, because router
+// middlewares do not run for OPTIONS requests to endpoints that do not
+// accept OPTIONS method.
+func (server *HTTPServer) CORS(next http.Handler) http.Handler {
+	config := server.Config.CORS
+
+	allowedMethods := config.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = defaultCORSMethods
+	}
+
+	allowedHeaders := config.AllowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = defaultCORSHeaders
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// response differs for different origins, caches need to know it
+		addVary(w.Header(), "Origin")
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		allowed := server.isOriginAllowed(origin)
+
+		if preflight {
+			// preflight request is never passed to the endpoints, the
+			// browser rejects the actual request when headers are missing
+			if allowed && isCORSRequestAllowed(r, allowedMethods, allowedHeaders) {
+				server.setCORSOriginHeaders(w, origin)
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+				if config.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			server.setCORSOriginHeaders(w, origin)
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setCORSOriginHeaders allows the origin to read the response, wildcard
+// origin is never combined with credentials
+func (server *HTTPServer) setCORSOriginHeaders(w http.ResponseWriter, origin string) {
+	if server.Config.CORS.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	} else if stringInSlice("*", server.Config.CORS.AllowedOrigins) {
+		// any origin is allowed, so the origin does not need to be echoed
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+}
+
+// isOriginAllowed checks origin against the allowed origins, which can
+// contain wildcard subdomains
+func (server *HTTPServer) isOriginAllowed(origin string) bool {
+	for _, allowedOrigin := range server.Config.CORS.AllowedOrigins {
+		if allowedOrigin == "*" || strings.EqualFold(allowedOrigin, origin) {
+			return true
+		}
+
+		if matchesWildcardOrigin(allowedOrigin, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesWildcardOrigin checks whether origin is a subdomain of origin
+// pattern in the form scheme://*.domain[:port]
+func matchesWildcardOrigin(pattern, origin string) bool {
+	patternURL, err := url.Parse(pattern)
+	if err != nil || !strings.HasPrefix(patternURL.Host, "*.") {
+		return false
+	}
+
+	originURL, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(originURL.Scheme, patternURL.Scheme) {
+		return false
+	}
+
+	if originURL.Port() != patternURL.Port() {
+		return false
+	}
+
+	suffix := strings.ToLower(strings.TrimPrefix(patternURL.Hostname(), "*"))
+	host := strings.ToLower(originURL.Hostname())
+
+	return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+}
+
+// isCORSRequestAllowed checks method and headers announced by preflight request
+func isCORSRequestAllowed(r *http.Request, allowedMethods, allowedHeaders []string) bool {
+	if !stringInSlice(r.Header.Get("Access-Control-Request-Method"), allowedMethods) {
+		return false
+	}
+
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !headerInSlice(header, allowedHeaders) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// headerInSlice checks header name against list of names case-insensitively
+func headerInSlice(header string, headers []string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h, header) {
+			return true
+		}
+	}
+	return false
+}
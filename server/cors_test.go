@@ -0,0 +1,163 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+func corsConfig(allowedOrigins ...string) server.Configuration {
+	corsConfig := authConfig()
+	corsConfig.CORS = server.CORSConfiguration{
+		Enabled:        true,
+		AllowedOrigins: allowedOrigins,
+		MaxAge:         10 * time.Minute,
+	}
+	return corsConfig
+}
+
+func preflightRequest(origin, method, headers string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, config.APIPrefix, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return req
+}
+
+func TestCORSPreflight(t *testing.T) {
+	testServer := server.New(corsConfig("https://preview.example.com"))
+
+	rr := executeRequest(testServer, preflightRequest("https://preview.example.com", http.MethodGet, "x-rh-identity, x-request-id"))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://preview.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, HEAD", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "x-rh-identity")
+	assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+}
+
+func TestCORSPreflightRejected(t *testing.T) {
+	testServer := server.New(corsConfig("https://preview.example.com"))
+
+	for _, req := range []*http.Request{
+		preflightRequest("https://evil.example.org", http.MethodGet, ""),
+		preflightRequest("https://preview.example.com", http.MethodDelete, ""),
+		preflightRequest("https://preview.example.com", http.MethodGet, "X-Custom-Header"),
+	} {
+		rr := executeRequest(testServer, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+	}
+}
+
+func TestCORSWildcardSubdomain(t *testing.T) {
+	testServer := server.New(corsConfig("https://*.example.com"))
+
+	for origin, allowed := range map[string]bool{
+		"https://preview.example.com":    true,
+		"https://a.b.example.com":        true,
+		"https://PREVIEW.example.com":    true,
+		"https://example.com":            false,
+		"http://preview.example.com":     false,
+		"https://preview.example.com:81": false,
+		"https://evilexample.com":        false,
+		"https://example.com.evil.org":   false,
+	} {
+		rr := executeRequest(testServer, preflightRequest(origin, http.MethodGet, ""))
+
+		if allowed {
+			assert.Equal(t, origin, rr.Header().Get("Access-Control-Allow-Origin"), origin)
+		} else {
+			assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"), origin)
+		}
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	rr := executeRequest(server.New(corsConfig("*")), preflightRequest("https://anything.example.org", http.MethodGet, ""))
+
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSCredentials(t *testing.T) {
+	testConfig := corsConfig("https://*.example.com")
+	testConfig.CORS.AllowCredentials = true
+
+	rr := executeRequest(server.New(testConfig), preflightRequest("https://preview.example.com", http.MethodGet, ""))
+
+	// wildcard is not accepted by browsers for requests with credentials
+	assert.Equal(t, "https://preview.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSAnyOriginWithCredentialsRejected(t *testing.T) {
+	testConfig := corsConfig("https://preview.example.com", "*")
+	testConfig.CORS.AllowCredentials = true
+	testConfig.Address = freeAddress(t)
+
+	err := server.New(testConfig).Start()
+
+	assert.EqualError(t, err,
+		"CORS origin '*' can not be allowed together with credentials, list the allowed origins instead")
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	testServer := server.New(corsConfig("https://preview.example.com"))
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set("Origin", "https://preview.example.com")
+	req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{
+		AccountNumber: "1",
+		Internal:      server.Internal{OrgID: 1},
+	}))
+
+	rr := executeRequest(testServer, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://preview.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-ID, ETag, Retry-After", rr.Header().Get("Access-Control-Expose-Headers"))
+
+	// authentication still applies to requests from allowed origins
+	req.Header.Del("x-rh-identity")
+	rr = executeRequest(testServer, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "https://preview.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSDisabled(t *testing.T) {
+	testConfig := corsConfig("*")
+	testConfig.CORS.Enabled = false
+
+	rr := executeRequest(server.New(testConfig), preflightRequest("https://preview.example.com", http.MethodGet, ""))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+}
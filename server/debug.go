@@ -0,0 +1,153 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+)
+
+const injectedFaultMessage = "Injected fault"
+
+// RouteInfo describes one route registered in the router
+type RouteInfo struct {
+	Path    string   `json:"path"`
+	Methods []string `json:"methods,omitempty"`
+}
+
+// addDebugEndpointsToRouter registers endpoints that help with debugging of
+// the service, they are registered only in debug mode
+func (server *HTTPServer) addDebugEndpointsToRouter(router *mux.Router) {
+	apiPrefix := server.Config.APIPrefix
+
+	router.Handle(apiPrefix+DebugConfigEndpoint, server.adminHandler(server.debugConfigEndpoint)).Methods(http.MethodGet)
+	router.Handle(apiPrefix+DebugRoutesEndpoint, server.adminHandler(func(writer http.ResponseWriter, request *http.Request) {
+		server.debugRoutesEndpoint(router, writer, request)
+	})).Methods(http.MethodGet)
+
+	// pprof.Index expects the profiles at /debug/pprof/, so the named
+	// profiles are served by their own handlers
+	pprofPrefix := apiPrefix + DebugPprofEndpoint
+	router.Handle(pprofPrefix, server.adminHandler(pprof.Index)).Methods(http.MethodGet)
+	router.Handle(pprofPrefix+"cmdline", server.adminHandler(pprof.Cmdline)).Methods(http.MethodGet)
+	router.Handle(pprofPrefix+"profile", server.adminHandler(pprof.Profile)).Methods(http.MethodGet)
+	router.Handle(pprofPrefix+"symbol", server.adminHandler(pprof.Symbol)).Methods(http.MethodGet, http.MethodPost)
+	router.Handle(pprofPrefix+"trace", server.adminHandler(pprof.Trace)).Methods(http.MethodGet)
+	router.Handle(pprofPrefix+"{profile}", server.adminHandler(func(writer http.ResponseWriter, request *http.Request) {
+		pprof.Handler(mux.Vars(request)["profile"]).ServeHTTP(writer, request)
+	})).Methods(http.MethodGet)
+}
+
+// debugConfigEndpoint returns the effective configuration of the service
+// with secrets redacted
+func (server *HTTPServer) debugConfigEndpoint(writer http.ResponseWriter, request *http.Request) {
+	var config interface{} = server.Config
+	if server.DebugConfig != nil {
+		config = server.DebugConfig
+	}
+
+	err := responses.SendResponse(writer, responses.BuildOkResponseWithData("config", config))
+	if err != nil {
+		requestLogger(request).Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// debugRoutesEndpoint returns all routes registered in the router
+func (server *HTTPServer) debugRoutesEndpoint(router *mux.Router, writer http.ResponseWriter, request *http.Request) {
+	routes := []RouteInfo{}
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			// routes without path can not be requested
+			return nil
+		}
+
+		// routes without methods accept any method
+		methods, _ := route.GetMethods()
+		routes = append(routes, RouteInfo{Path: path, Methods: methods})
+		return nil
+	})
+	if err != nil {
+		handleServerError(writer, request, err)
+		return
+	}
+
+	err = responses.SendResponse(writer, responses.BuildOkResponseWithData("routes", routes))
+	if err != nil {
+		requestLogger(request).Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// FaultInjection middleware delays responses of configured endpoints and
+// makes part of them fail, it is used only in debug mode
+func (server *HTTPServer) FaultInjection(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fault, found := server.findFault(r)
+		if !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if fault.Latency > 0 {
+			timer := time.NewTimer(fault.Latency)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+
+		// #nosec G404
+		if fault.ErrorRate > 0 && rand.Float64() < fault.ErrorRate {
+			statusCode := fault.StatusCode
+			if statusCode == 0 {
+				statusCode = http.StatusInternalServerError
+			}
+
+			err := responses.Send(statusCode, w, injectedFaultMessage)
+			if err != nil {
+				requestLogger(r).Error().Err(err).Msg(responseDataError)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// findFault returns fault configured for the requested endpoint
+func (server *HTTPServer) findFault(r *http.Request) (FaultConfiguration, bool) {
+	endpoint, found := server.endpointName(r)
+	if !found {
+		return FaultConfiguration{}, false
+	}
+
+	for _, fault := range server.Config.Faults {
+		if fault.Endpoint == endpoint {
+			return fault, true
+		}
+	}
+
+	return FaultConfiguration{}, false
+}
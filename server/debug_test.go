@@ -0,0 +1,179 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+	"github.com/RedHatInsights/insights-content-service/types"
+)
+
+func debugConfig(debug bool) server.Configuration {
+	debugConfig := config
+	debugConfig.Debug = debug
+	debugConfig.RoleBindings = []server.RoleBinding{{Role: server.RoleOperator, OrgIDs: []types.OrgID{1}}}
+	return debugConfig
+}
+
+// operatorRequest makes request sent by organization bound to operator role
+// by debugConfig
+func operatorRequest(t *testing.T, method, url string) *http.Request {
+	req := httptest.NewRequest(method, url, nil)
+	req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{
+		AccountNumber: "1",
+		Internal:      server.Internal{OrgID: 1},
+	}))
+	return req
+}
+
+func TestDebugEndpointsNotRegistered(t *testing.T) {
+	testServer := server.New(debugConfig(false))
+
+	for _, endpoint := range []string{server.DebugConfigEndpoint, server.DebugRoutesEndpoint, server.DebugPprofEndpoint} {
+		rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, config.APIPrefix+endpoint, nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code, endpoint)
+	}
+}
+
+func TestDebugConfigEndpoint(t *testing.T) {
+	testServer := server.New(debugConfig(true))
+	testServer.DebugConfig = map[string]string{"secret": "[REDACTED]"}
+
+	rr := executeRequest(testServer, operatorRequest(t, http.MethodGet, config.APIPrefix+server.DebugConfigEndpoint))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status": "ok", "config": {"secret": "[REDACTED]"}}`, rr.Body.String())
+}
+
+func TestDebugRoutesEndpoint(t *testing.T) {
+	rr := executeRequest(server.New(debugConfig(true)), operatorRequest(t, http.MethodGet, config.APIPrefix+server.DebugRoutesEndpoint))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `{"path":"/api/test/info","methods":["GET"]}`)
+	assert.Contains(t, rr.Body.String(), `"path":"/api/test/debug/pprof/{profile}"`)
+}
+
+func TestDebugPprofEndpoints(t *testing.T) {
+	testServer := server.New(debugConfig(true))
+
+	rr := executeRequest(testServer, operatorRequest(t, http.MethodGet, config.APIPrefix+server.DebugPprofEndpoint))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "goroutine")
+
+	rr = executeRequest(testServer, operatorRequest(t, http.MethodGet, config.APIPrefix+server.DebugPprofEndpoint+"goroutine?debug=1"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "goroutine profile")
+}
+
+func TestDebugEndpointsRequireOperatorRole(t *testing.T) {
+	testConfig := authConfig()
+	testConfig.Debug = true
+
+	identity := server.Identity{AccountNumber: "1", Internal: server.Internal{OrgID: 1}}
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.DebugRoutesEndpoint, nil)
+	req.Header.Set("x-rh-identity", makeXRHToken(t, identity))
+	rr := executeRequest(server.New(testConfig), req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	testConfig.RoleBindings = []server.RoleBinding{{Role: server.RoleOperator, OrgIDs: []types.OrgID{1}}}
+	req = httptest.NewRequest(http.MethodGet, config.APIPrefix+server.DebugRoutesEndpoint, nil)
+	req.Header.Set("x-rh-identity", makeXRHToken(t, identity))
+	rr = executeRequest(server.New(testConfig), req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDebugEndpointsRequireAuthWithAuthDisabled(t *testing.T) {
+	testServer := server.New(debugConfig(true))
+
+	for _, endpoint := range []string{server.DebugConfigEndpoint, server.DebugRoutesEndpoint, server.DebugPprofEndpoint, server.DebugPprofEndpoint + "heap"} {
+		rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, config.APIPrefix+endpoint, nil))
+		assert.Equal(t, http.StatusForbidden, rr.Code, endpoint)
+	}
+
+	// organization not bound to operator role
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.DebugConfigEndpoint, nil)
+	req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{AccountNumber: "2", Internal: server.Internal{OrgID: 2}}))
+	rr := executeRequest(testServer, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// other endpoints stay without auth.
+	rr = executeRequest(testServer, httptest.NewRequest(http.MethodGet, config.APIPrefix+server.InfoEndpoint, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestFaultInjectionErrors(t *testing.T) {
+	testConfig := debugConfig(true)
+	testConfig.Faults = []server.FaultConfiguration{
+		{Endpoint: server.InfoEndpoint, ErrorRate: 1, StatusCode: http.StatusBadGateway},
+	}
+	testServer := server.New(testConfig)
+
+	rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, config.APIPrefix+server.InfoEndpoint, nil))
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+	assert.JSONEq(t, `{"status": "Injected fault"}`, rr.Body.String())
+
+	// other endpoints are not affected
+	rr = executeRequest(testServer, httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestFaultInjectionLatency(t *testing.T) {
+	testConfig := debugConfig(true)
+	testConfig.Faults = []server.FaultConfiguration{
+		{Endpoint: server.MainEndpoint, Latency: 50 * time.Millisecond},
+	}
+
+	start := time.Now()
+	rr := executeRequest(server.New(testConfig), httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+}
+
+func TestFaultInjectionCanceledRequest(t *testing.T) {
+	testConfig := debugConfig(true)
+	testConfig.Faults = []server.FaultConfiguration{
+		{Endpoint: server.MainEndpoint, Latency: time.Hour},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil).WithContext(ctx)
+	rr := executeRequest(server.New(testConfig), req)
+
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestFaultInjectionDisabledWithoutDebug(t *testing.T) {
+	testConfig := debugConfig(false)
+	testConfig.Faults = []server.FaultConfiguration{
+		{Endpoint: server.MainEndpoint, ErrorRate: 1},
+	}
+
+	rr := executeRequest(server.New(testConfig), httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// MainEndpoint returns status ok
+	MainEndpoint = ""
+	// MetricsEndpoint returns Prometheus metrics
+	MetricsEndpoint = "metrics"
+	// InfoEndpoint returns build information and version of the served content
+	InfoEndpoint = "info"
+	// SearchEndpoint returns rules matching full-text query
+	SearchEndpoint = "search"
+	// RulesEndpoint returns filtered and sorted page of rule error keys
+	RulesEndpoint = "rules"
+	// RuleContentEndpoint returns content of listed rule error keys
+	RuleContentEndpoint = "rules/content"
+	// ContentReloadEndpoint forces reload of the served content, it is
+	// available to content admins only
+	ContentReloadEndpoint = "content/reload"
+
+	// DebugConfigEndpoint returns effective configuration, it is
+	// available in debug mode only
+	DebugConfigEndpoint = "debug/config"
+	// DebugRoutesEndpoint returns all registered routes, it is available
+	// in debug mode only
+	DebugRoutesEndpoint = "debug/routes"
+	// DebugPprofEndpoint is the prefix of pprof profiles, it is available
+	// in debug mode only
+	DebugPprofEndpoint = "debug/pprof/"
+
+	// LivenessEndpoint reports that the service is running, unlike other
+	// endpoints it is not prefixed by API prefix
+	LivenessEndpoint = "/health/live"
+	// ReadinessEndpoint reports whether the service has content to serve,
+	// unlike other endpoints it is not prefixed by API prefix
+	ReadinessEndpoint = "/health/ready"
+)
+
+func (server *HTTPServer) addEndpointsToRouter(router *mux.Router) {
+	apiPrefix := server.Config.APIPrefix
+	openAPIURL := apiPrefix + filepath.Base(server.Config.APISpecFile)
+
+	// common REST API endpoints
+	router.HandleFunc(apiPrefix+MainEndpoint, server.mainEndpoint).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+InfoEndpoint, server.infoEndpoint).Methods(http.MethodGet)
+	router.Handle(apiPrefix+SearchEndpoint, server.ConditionalContent(http.HandlerFunc(server.searchEndpoint))).Methods(http.MethodGet)
+	router.Handle(apiPrefix+RulesEndpoint, server.ConditionalContent(http.HandlerFunc(server.rulesEndpoint))).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+RuleContentEndpoint, server.ruleContentEndpoint).Methods(http.MethodPost)
+	router.Handle(apiPrefix+ContentReloadEndpoint, server.adminHandler(server.contentReloadEndpoint)).Methods(http.MethodPost)
+
+	// OpenAPI specs
+	router.HandleFunc(openAPIURL, server.serveAPISpecFile).Methods(http.MethodGet)
+
+	// Prometheus metrics
+	router.Handle(apiPrefix+MetricsEndpoint, promhttp.Handler()).Methods(http.MethodGet)
+
+	// Kubernetes probes
+	router.HandleFunc(LivenessEndpoint, server.livenessEndpoint).Methods(http.MethodGet)
+	router.HandleFunc(ReadinessEndpoint, server.readinessEndpoint).Methods(http.MethodGet)
+
+	if server.Config.Debug {
+		server.addDebugEndpointsToRouter(router)
+	}
+}
@@ -15,11 +15,18 @@
 package server
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
 )
 
+// responseDataError is used as the error message when the responses functions return an error
+const responseDataError = "Unexpected error during response data encoding"
+
 // AuthenticationError happens during auth problems, for example malformed token
 type AuthenticationError struct {
 	errString string
@@ -29,7 +36,88 @@ func (e *AuthenticationError) Error() string {
 	return e.errString
 }
 
-// handleServerError handles separate server errors and sends appropriate responses
-func handleServerError(writer http.ResponseWriter, err error) {
-	log.Error().Err(err).Msg("handleServerError()")
+// AuthorizationError happens when the authenticated identity does not hold
+// any role permitted to access the requested endpoint
+type AuthorizationError struct {
+	errString string
+}
+
+func (e *AuthorizationError) Error() string {
+	return e.errString
+}
+
+// ParameterError happens when query parameter has invalid value
+type ParameterError struct {
+	paramName  string
+	paramValue string
+	errString  string
+}
+
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("Invalid value '%s' of parameter '%s': %s", e.paramValue, e.paramName, e.errString)
+}
+
+// RequestBodyError happens when request body can not be parsed or it is
+// too large
+type RequestBodyError struct {
+	errString string
+}
+
+func (e *RequestBodyError) Error() string {
+	return e.errString
+}
+
+// handleServerError handles separate server errors and sends appropriate
+// responses, the error is logged by logger attached to the request. Requests
+// rejected because of caller's mistake are expected, so they are logged at
+// debug level only, authentication and authorization failures are written
+// to audit log too.
+func handleServerError(writer http.ResponseWriter, request *http.Request, err error) {
+	logger := requestLogger(request)
+	if isClientError(err) {
+		logger.Debug().Err(err).Msg("Request rejected")
+	} else {
+		logger.Error().Err(err).Msg("handleServerError()")
+	}
+
+	var respErr error
+
+	switch err := err.(type) {
+	case *AuthenticationError, *AuthorizationError:
+		respErr = responses.SendForbidden(writer, err.Error())
+	case *RateLimitError:
+		writer.Header().Set("Retry-After", retryAfterSeconds(err.RetryAfter))
+		respErr = responses.Send(http.StatusTooManyRequests, writer, err.Error())
+	case *ParameterError, *RequestBodyError:
+		respErr = responses.SendError(writer, err.Error())
+	case *NotAcceptableError:
+		respErr = responses.Send(http.StatusNotAcceptable, writer, err.Error())
+	default:
+		respErr = responses.SendInternalServerError(writer, "Internal Server Error")
+	}
+
+	if respErr != nil {
+		logger.Error().Err(respErr).Msg(responseDataError)
+	}
+}
+
+// isClientError checks whether the error is caused by the caller
+func isClientError(err error) bool {
+	switch err.(type) {
+	case *AuthenticationError, *AuthorizationError, *RateLimitError,
+		*ParameterError, *RequestBodyError, *NotAcceptableError:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfterSeconds formats delay as value of Retry-After header, which is
+// a whole number of seconds
+func retryAfterSeconds(delay time.Duration) string {
+	seconds := int(math.Ceil(delay.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
 }
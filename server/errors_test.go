@@ -15,3 +15,35 @@ limitations under the License.
 */
 
 package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+func TestRejectedRequestsLoggedAtDebugLevel(t *testing.T) {
+	buffer, restore := captureLog()
+	defer restore()
+
+	rr := executeRequest(server.New(authConfig()), httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, buffer.String(), `"level":"debug"`)
+	assert.Contains(t, buffer.String(), "Missing auth token")
+	assert.NotContains(t, buffer.String(), `"level":"error"`)
+}
+
+func TestInvalidParametersLoggedAtDebugLevel(t *testing.T) {
+	buffer, restore := captureLog()
+	defer restore()
+
+	rr := executeRequest(server.New(config), httptest.NewRequest(http.MethodGet, config.APIPrefix+server.SearchEndpoint, nil))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.NotContains(t, buffer.String(), `"level":"error"`)
+}
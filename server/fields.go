@@ -0,0 +1,147 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// FieldsParameter is the name of query parameter listing fields that
+// should be included in content responses
+const FieldsParameter = "fields"
+
+// fieldTree is parsed list of fields, nil subtree selects the whole value
+// of the field
+type fieldTree map[string]fieldTree
+
+// parseFields parses comma separated list of fields, nested fields are
+// separated by dots
+func parseFields(fields string) (fieldTree, error) {
+	tree := fieldTree{}
+
+	for _, path := range strings.Split(fields, ",") {
+		names := strings.Split(strings.TrimSpace(path), ".")
+
+		node := tree
+		for i, name := range names {
+			if name == "" {
+				return nil, &ParameterError{paramName: FieldsParameter, paramValue: fields,
+					errString: "field names must not be empty"}
+			}
+
+			subtree, found := node[name]
+			if found && subtree == nil {
+				// the whole field has been selected already
+				break
+			}
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+			if !found {
+				subtree = fieldTree{}
+				node[name] = subtree
+			}
+			node = subtree
+		}
+	}
+
+	return tree, nil
+}
+
+// SelectFields limits value to fields listed in fields query parameter,
+// the value is returned unchanged when the parameter is not set. Rule
+// listing uses it for the listed rules. Fields are named as in JSON
+// encoding of the value, nested fields are separated by dots and fields of
+// arrays apply to all their items, so for example error_keys.metadata.impact
+// selects impact of every error key of a rule. Fields that do not exist are
+// ignored. The selected fields are returned as generic maps and arrays, so
+// gob clients have to decode them into maps instead of the original type.
+func (server *HTTPServer) SelectFields(r *http.Request, value interface{}) (interface{}, error) {
+	tree, err := requestedFields(r)
+	if err != nil || tree == nil {
+		return value, err
+	}
+
+	return tree.apply(value)
+}
+
+// requestedFields returns fields listed in fields query parameter, nil tree
+// is returned when the parameter is not set
+func requestedFields(r *http.Request) (fieldTree, error) {
+	fields := r.URL.Query().Get(FieldsParameter)
+	if fields == "" {
+		return nil, nil
+	}
+
+	return parseFields(fields)
+}
+
+// apply returns only the selected fields of value
+func (tree fieldTree) apply(value interface{}) (interface{}, error) {
+	// the value is converted to generic maps and arrays first, so the
+	// field names are the same as in JSON
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic interface{}
+	err = decoder.Decode(&generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectFields(generic, tree), nil
+}
+
+// selectFields returns only the selected fields of generic value, numbers
+// are converted back from json.Number so all formats encode them as numbers
+func selectFields(value interface{}, tree fieldTree) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		selected := map[string]interface{}{}
+		for name, item := range value {
+			subtree, found := tree[name]
+			if tree != nil && !found {
+				continue
+			}
+			selected[name] = selectFields(item, subtree)
+		}
+		return selected
+	case []interface{}:
+		selected := make([]interface{}, len(value))
+		for i, item := range value {
+			selected[i] = selectFields(item, tree)
+		}
+		return selected
+	case json.Number:
+		if integer, err := value.Int64(); err == nil {
+			return integer
+		}
+		float, _ := value.Float64()
+		return float
+	default:
+		return value
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/formats"
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+type errorKeyMetadata struct {
+	Impact     int `json:"impact"`
+	Likelihood int `json:"likelihood"`
+}
+
+type errorKey struct {
+	Name     string           `json:"name"`
+	Generic  string           `json:"generic"`
+	Metadata errorKeyMetadata `json:"metadata"`
+}
+
+type ruleContent struct {
+	Summary   string     `json:"summary"`
+	Reason    string     `json:"reason"`
+	Tags      []string   `json:"tags"`
+	TotalRisk float64    `json:"total_risk"`
+	ErrorKeys []errorKey `json:"error_keys"`
+}
+
+var selectedRule = ruleContent{
+	Summary:   "Etcd reports high disk latency",
+	Reason:    "Long markdown text",
+	Tags:      []string{"etcd", "performance"},
+	TotalRisk: 2.5,
+	ErrorKeys: []errorKey{
+		{Name: "SLOW_DISK", Generic: "Long markdown text", Metadata: errorKeyMetadata{Impact: 2, Likelihood: 3}},
+		{Name: "SLOW_FSYNC", Generic: "Long markdown text", Metadata: errorKeyMetadata{Impact: 3, Likelihood: 1}},
+	},
+}
+
+func selectFields(t *testing.T, query string) interface{} {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+query, nil)
+
+	selected, err := server.New(config).SelectFields(req, selectedRule)
+	assert.NoError(t, err)
+	return selected
+}
+
+func TestSelectFieldsWithoutParameter(t *testing.T) {
+	assert.Equal(t, selectedRule, selectFields(t, ""))
+}
+
+func TestSelectFields(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"summary":    "Etcd reports high disk latency",
+		"tags":       []interface{}{"etcd", "performance"},
+		"total_risk": 2.5,
+	}, selectFields(t, "?fields=summary,tags,total_risk"))
+}
+
+func TestSelectNestedFields(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"summary": "Etcd reports high disk latency",
+		"error_keys": []interface{}{
+			map[string]interface{}{"name": "SLOW_DISK", "metadata": map[string]interface{}{"impact": int64(2)}},
+			map[string]interface{}{"name": "SLOW_FSYNC", "metadata": map[string]interface{}{"impact": int64(3)}},
+		},
+	}, selectFields(t, "?fields=summary,error_keys.metadata.impact,error_keys.name"))
+}
+
+func TestSelectWholeAndNestedField(t *testing.T) {
+	expected := map[string]interface{}{
+		"error_keys": []interface{}{
+			map[string]interface{}{"metadata": map[string]interface{}{"impact": int64(2), "likelihood": int64(3)}},
+			map[string]interface{}{"metadata": map[string]interface{}{"impact": int64(3), "likelihood": int64(1)}},
+		},
+	}
+
+	assert.Equal(t, expected, selectFields(t, "?fields=error_keys.metadata,error_keys.metadata.impact"))
+	assert.Equal(t, expected, selectFields(t, "?fields=error_keys.metadata.impact,error_keys.metadata"))
+}
+
+func TestSelectUnknownFields(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"summary": "Etcd reports high disk latency",
+	}, selectFields(t, "?fields=summary,description,summary.text"))
+}
+
+func TestSelectFieldsInvalid(t *testing.T) {
+	for _, fields := range []string{",", "summary,", "error_keys..impact", ".summary"} {
+		req := httptest.NewRequest(http.MethodGet, config.APIPrefix+"?fields="+fields, nil)
+
+		_, err := server.New(config).SelectFields(req, selectedRule)
+		assert.IsType(t, &server.ParameterError{}, err, fields)
+	}
+}
+
+func TestSelectFieldsAllFormats(t *testing.T) {
+	testServer := server.New(config)
+
+	for _, format := range []string{formats.JSON, formats.YAML, formats.MessagePack} {
+		req := httptest.NewRequest(http.MethodGet, config.APIPrefix+"?fields=summary,error_keys.metadata.impact&format="+format, nil)
+
+		selected, err := testServer.SelectFields(req, selectedRule)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		testServer.SendContent(rr, req, selected)
+		assert.Equal(t, http.StatusOK, rr.Code, format)
+
+		var decoded ruleContent
+		assert.NoError(t, formats.Decode(format, rr.Body, &decoded), format)
+		assert.Equal(t, ruleContent{
+			Summary: selectedRule.Summary,
+			ErrorKeys: []errorKey{
+				{Metadata: errorKeyMetadata{Impact: 2}},
+				{Metadata: errorKeyMetadata{Impact: 3}},
+			},
+		}, decoded, format)
+	}
+}
+
+func TestSelectFieldsGob(t *testing.T) {
+	testServer := server.New(config)
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+"?fields=summary,error_keys.metadata.impact&format=gob", nil)
+
+	selected, err := testServer.SelectFields(req, selectedRule)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	testServer.SendContent(rr, req, selected)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// gob encodes selected fields as generic map, not as the original type
+	var decoded map[string]interface{}
+	assert.NoError(t, formats.Decode(formats.Gob, rr.Body, &decoded))
+	assert.Equal(t, selected, decoded)
+}
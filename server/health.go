@@ -0,0 +1,180 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+
+	"github.com/RedHatInsights/insights-content-service/metrics"
+	"github.com/RedHatInsights/insights-content-service/search"
+)
+
+// Outcomes of the most recent content load
+const (
+	ContentLoadStatusNone    = "none"
+	ContentLoadStatusSuccess = "success"
+	ContentLoadStatusFailure = "failure"
+)
+
+const (
+	readinessStatusReady    = "ready"
+	readinessStatusNotReady = "not ready"
+
+	storageStatusOK          = "ok"
+	storageStatusUnreachable = "unreachable"
+)
+
+// ContentStatus describes the content served by the service and the outcome
+// of the most recent attempt to load it
+type ContentStatus struct {
+	// Version, Revision and Rules describe the content loaded by the
+	// last successful load, they are kept when a later load fails
+	Version  string     `json:"version"`
+	Revision string     `json:"revision"`
+	Rules    int        `json:"rules"`
+	LoadedAt *time.Time `json:"loaded_at"`
+	// LastLoadStatus is the outcome of the most recent load, it is none
+	// until content is loaded for the first time
+	LastLoadStatus string `json:"last_load_status"`
+	LastLoadError  string `json:"last_load_error,omitempty"`
+}
+
+// contentState holds content status shared by the content loader and
+// the HTTP handlers
+type contentState struct {
+	mutex  sync.RWMutex
+	status ContentStatus
+	// index is full-text index of the content used by search endpoint
+	index *search.Index
+	// rules are listed by rules endpoint
+	rules []RuleListItem
+}
+
+// readinessResponse is the body of readiness endpoint response
+type readinessResponse struct {
+	Status  string        `json:"status"`
+	Content ContentStatus `json:"content"`
+	Storage string        `json:"storage,omitempty"`
+	// ShuttingDown is set when the instance is draining connections
+	ShuttingDown bool `json:"shutting_down,omitempty"`
+}
+
+// RecordContentLoad records the outcome of an attempt to load content and
+// updates content inventory metrics. The content of the last successful load
+// is considered to be served when the load fails.
+func (server *HTTPServer) RecordContentLoad(stats metrics.ContentLoadStats, duration time.Duration, loadErr error) {
+	metrics.RecordContentLoad(stats, duration, loadErr)
+
+	server.content.mutex.Lock()
+	defer server.content.mutex.Unlock()
+
+	status := &server.content.status
+
+	if loadErr != nil {
+		status.LastLoadStatus = ContentLoadStatusFailure
+		status.LastLoadError = loadErr.Error()
+		return
+	}
+
+	rules := 0
+	for _, count := range stats.RulesByStatus {
+		rules += count
+	}
+
+	loadedAt := time.Now().UTC()
+
+	status.Version = stats.Version
+	status.Revision = stats.Revision
+	status.Rules = rules
+	status.LoadedAt = &loadedAt
+	status.LastLoadStatus = ContentLoadStatusSuccess
+	status.LastLoadError = ""
+}
+
+// ContentStatus returns status of the content served by the service
+func (server *HTTPServer) ContentStatus() ContentStatus {
+	server.content.mutex.RLock()
+	defer server.content.mutex.RUnlock()
+
+	status := server.content.status
+	if status.LastLoadStatus == "" {
+		status.LastLoadStatus = ContentLoadStatusNone
+	}
+
+	return status
+}
+
+// key identifies the loaded content, it changes on every successful load
+func (status ContentStatus) key() string {
+	key := status.Version + "\x00" + status.Revision
+	if status.LoadedAt != nil {
+		key += "\x00" + status.LoadedAt.String()
+	}
+	return key
+}
+
+// livenessEndpoint reports that the process is running and able to serve
+// HTTP requests
+func (server *HTTPServer) livenessEndpoint(writer http.ResponseWriter, request *http.Request) {
+	err := responses.SendResponse(writer, responses.BuildOkResponse())
+	if err != nil {
+		requestLogger(request).Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// readinessEndpoint reports whether the service has content to serve (when
+// content loader is enabled), its storage backend is reachable and it is not
+// shutting down
+func (server *HTTPServer) readinessEndpoint(writer http.ResponseWriter, request *http.Request) {
+	response := readinessResponse{
+		Status:  readinessStatusReady,
+		Content: server.ContentStatus(),
+	}
+
+	if server.ContentLoaderEnabled && response.Content.LoadedAt == nil {
+		response.Status = readinessStatusNotReady
+	}
+
+	if server.isDraining() {
+		response.ShuttingDown = true
+		response.Status = readinessStatusNotReady
+	}
+
+	if server.StorageCheck != nil {
+		response.Storage = storageStatusOK
+
+		if err := server.StorageCheck(); err != nil {
+			requestLogger(request).Warn().Err(err).Msg("Storage backend is unreachable")
+			response.Storage = storageStatusUnreachable
+			response.Status = readinessStatusNotReady
+		}
+	}
+
+	statusCode := http.StatusOK
+	if response.Status != readinessStatusReady {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	err := responses.Send(statusCode, writer, response)
+	if err != nil {
+		requestLogger(request).Error().Err(err).Msg(responseDataError)
+	}
+}
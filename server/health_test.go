@@ -0,0 +1,123 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/metrics"
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+var loadedContent = metrics.ContentLoadStats{
+	RulesByStatus: map[string]int{"active": 3, "inactive": 1},
+	Version:       "1.2.3",
+	Revision:      "abcdef",
+}
+
+func TestLivenessEndpoint(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, server.LivenessEndpoint, nil)
+
+	rr := executeRequest(server.New(config), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, rr.Body.String())
+}
+
+func TestReadinessWithoutContentLoader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, server.ReadinessEndpoint, nil)
+
+	rr := executeRequest(server.New(config), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"status": "ready",
+		"content": {"version": "", "revision": "", "rules": 0, "loaded_at": null, "last_load_status": "none"}
+	}`, rr.Body.String())
+}
+
+func TestReadinessBeforeContentLoad(t *testing.T) {
+	testServer := server.New(config)
+	testServer.ContentLoaderEnabled = true
+	req := httptest.NewRequest(http.MethodGet, server.ReadinessEndpoint, nil)
+
+	rr := executeRequest(testServer, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.JSONEq(t, `{
+		"status": "not ready",
+		"content": {"version": "", "revision": "", "rules": 0, "loaded_at": null, "last_load_status": "none"}
+	}`, rr.Body.String())
+}
+
+func TestReadinessAfterContentLoad(t *testing.T) {
+	testServer := server.New(config)
+	testServer.ContentLoaderEnabled = true
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, server.ReadinessEndpoint, nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status":"ready"`)
+	assert.Contains(t, rr.Body.String(), `"version":"1.2.3"`)
+	assert.Contains(t, rr.Body.String(), `"last_load_status":"success"`)
+}
+
+func TestReadinessAfterFailedReload(t *testing.T) {
+	testServer := server.New(config)
+	testServer.ContentLoaderEnabled = true
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+	testServer.RecordContentLoad(metrics.ContentLoadStats{}, time.Second, errors.New("invalid YAML"))
+
+	rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, server.ReadinessEndpoint, nil))
+
+	// the previously loaded content is still served
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	status := testServer.ContentStatus()
+	assert.Equal(t, "1.2.3", status.Version)
+	assert.Equal(t, 4, status.Rules)
+	assert.Equal(t, server.ContentLoadStatusFailure, status.LastLoadStatus)
+	assert.Equal(t, "invalid YAML", status.LastLoadError)
+}
+
+func TestReadinessStorageUnreachable(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+	testServer.StorageCheck = func() error { return errors.New("connection refused") }
+
+	rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, server.ReadinessEndpoint, nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"storage":"unreachable"`)
+}
+
+func TestHealthEndpointsWithoutAuth(t *testing.T) {
+	testServer := server.New(authConfig())
+
+	rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, server.LivenessEndpoint, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = executeRequest(testServer, httptest.NewRequest(http.MethodGet, server.ReadinessEndpoint, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
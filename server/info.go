@@ -0,0 +1,53 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+)
+
+// BuildInfo describes the build of the service binary
+type BuildInfo struct {
+	Version string `json:"version"`
+	Time    string `json:"time"`
+	Branch  string `json:"branch"`
+	Commit  string `json:"commit"`
+}
+
+// Info describes code and content served by the service instance
+type Info struct {
+	Build     BuildInfo     `json:"build"`
+	GoVersion string        `json:"go_version"`
+	Content   ContentStatus `json:"content"`
+}
+
+// infoEndpoint returns build information and version of the served content
+func (server *HTTPServer) infoEndpoint(writer http.ResponseWriter, request *http.Request) {
+	info := Info{
+		Build:     server.BuildInfo,
+		GoVersion: runtime.Version(),
+		Content:   server.ContentStatus(),
+	}
+
+	err := responses.SendResponse(writer, responses.BuildOkResponseWithData("info", info))
+	if err != nil {
+		requestLogger(request).Error().Err(err).Msg(responseDataError)
+	}
+}
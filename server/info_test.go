@@ -0,0 +1,64 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+func TestInfoEndpoint(t *testing.T) {
+	testServer := server.New(config)
+	testServer.BuildInfo = server.BuildInfo{
+		Version: "0.1",
+		Time:    "2020-05-20T10:00:00Z",
+		Branch:  "master",
+		Commit:  "0123456",
+	}
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, config.APIPrefix+server.InfoEndpoint, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Status string      `json:"status"`
+		Info   server.Info `json:"info"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, "ok", response.Status)
+	assert.Equal(t, testServer.BuildInfo, response.Info.Build)
+	assert.Equal(t, runtime.Version(), response.Info.GoVersion)
+	assert.Equal(t, "1.2.3", response.Info.Content.Version)
+	assert.Equal(t, "abcdef", response.Info.Content.Revision)
+	assert.Equal(t, 4, response.Info.Content.Rules)
+	assert.NotNil(t, response.Info.Content.LoadedAt)
+}
+
+func TestInfoEndpointRequiresAuth(t *testing.T) {
+	rr := executeRequest(server.New(authConfig()), httptest.NewRequest(http.MethodGet, config.APIPrefix+server.InfoEndpoint, nil))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
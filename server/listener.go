@@ -0,0 +1,129 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// unixAddressPrefix marks address of Unix domain socket
+	unixAddressPrefix = "unix://"
+
+	// systemdFirstListenFD is the first file descriptor passed by systemd
+	// socket activation
+	systemdFirstListenFD = 3
+
+	// staleSocketDialTimeout limits time spent by checking whether existing
+	// socket is still served by another process
+	staleSocketDialTimeout = time.Second
+)
+
+// listen returns listener passed by systemd socket activation or, when the
+// service is not socket activated, listener on the configured address. The
+// address is either TCP address or path of Unix domain socket prefixed by
+// unix://
+func (server *HTTPServer) listen() (net.Listener, error) {
+	listener, activated, err := systemdListener()
+	if err != nil || activated {
+		return listener, err
+	}
+
+	address := server.Config.Address
+	if !strings.HasPrefix(address, unixAddressPrefix) {
+		return net.Listen("tcp", address)
+	}
+
+	path := strings.TrimPrefix(address, unixAddressPrefix)
+	err = removeStaleSocket(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.Listen("unix", path)
+}
+
+// removeStaleSocket removes socket file left by previous instance of the
+// service, which would prevent binding to the same path. The socket is
+// removed only when nobody listens on it, socket of running instance is
+// never taken over.
+func removeStaleSocket(path string) error {
+	fileInfo, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if fileInfo.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("unable to listen on '%s', the file exists and it is not a socket", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, staleSocketDialTimeout)
+	if err == nil {
+		_ = conn.Close()
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("unable to listen on '%s', address already in use", path)
+	}
+
+	log.Info().Msgf("Removing stale socket '%s'", path)
+	return os.Remove(path)
+}
+
+// systemdListener returns listener passed by systemd socket activation. The
+// environment variables are unset, so they are not inherited by child
+// processes.
+func systemdListener() (net.Listener, bool, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, false, nil
+	}
+
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, false, nil
+	}
+
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(name)
+	}
+
+	if fds > 1 {
+		log.Warn().Msgf("Systemd passed %d sockets, only the first one is used", fds)
+	}
+
+	file := os.NewFile(uintptr(systemdFirstListenFD), "systemd-socket")
+	defer func() { _ = file.Close() }()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, true, fmt.Errorf("unable to use socket passed by systemd: %s", err)
+	}
+
+	log.Info().Msgf("Using socket '%s' passed by systemd", listener.Addr())
+	return listener, true, nil
+}
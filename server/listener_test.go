@@ -0,0 +1,113 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+// unixSocketClient returns HTTP client connecting to given Unix domain socket
+func unixSocketClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestUnixSocketListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "socket")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	socket := filepath.Join(dir, "content-service.sock")
+
+	// socket left by previous instance is replaced
+	stale, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	assert.NoError(t, stale.Close())
+
+	testConfig := config
+	testConfig.Address = "unix://" + socket
+	testServer := server.New(testConfig)
+
+	serverErrors := startServer(t, testServer)
+
+	response, err := unixSocketClient(socket).Get("http://content-service" + config.APIPrefix)
+	if assert.NoError(t, err) {
+		_ = response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+
+	assert.NoError(t, testServer.Shutdown())
+	assert.NoError(t, <-serverErrors)
+}
+
+func TestUnixSocketListenerInUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "socket")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	socket := filepath.Join(dir, "content-service.sock")
+
+	// socket of another running instance is not taken over
+	running, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	defer func() { _ = running.Close() }()
+
+	testConfig := config
+	testConfig.Address = "unix://" + socket
+
+	err = server.New(testConfig).Start()
+	assert.EqualError(t, err, "unable to listen on '"+socket+"', address already in use")
+
+	conn, err := net.Dial("unix", socket)
+	if assert.NoError(t, err) {
+		_ = conn.Close()
+	}
+}
+
+func TestUnixSocketListenerOverRegularFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "socket")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "data.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{}"), 0600))
+
+	testConfig := config
+	testConfig.Address = "unix://" + path
+
+	err = server.New(testConfig).Start()
+	assert.EqualError(t, err, "unable to listen on '"+path+"', the file exists and it is not a socket")
+
+	// the file is not removed
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
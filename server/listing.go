@@ -0,0 +1,334 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query parameters controlling sorting and pagination of listings
+const (
+	LimitParameter = "limit"
+	NextParameter  = "next"
+	SortParameter  = "sort"
+)
+
+const (
+	// DefaultListLimit is the page size used when limit is not specified
+	DefaultListLimit = 50
+	// MaxListLimit is the largest page size callers can request
+	MaxListLimit = 1000
+
+	// rangeSeparator separates lower and upper bound of range filters
+	rangeSeparator = ".."
+	// dateFormat is the format of dates in filters
+	dateFormat = "2006-01-02"
+)
+
+var (
+	errMalformedCursor = errors.New("malformed cursor")
+	errStaleCursor     = errors.New("content has changed since the listing was started")
+	errCursorMismatch  = errors.New("cursor belongs to listing with different filters or sorting")
+)
+
+// Fields the rule listing can be filtered and sorted by
+const (
+	RuleFieldTag         = "tag"
+	RuleFieldGroup       = "group"
+	RuleFieldImpact      = "impact"
+	RuleFieldLikelihood  = "likelihood"
+	RuleFieldTotalRisk   = "total_risk"
+	RuleFieldStatus      = "status"
+	RuleFieldPublishDate = "publish_date"
+	RuleFieldProductCode = "product_code"
+)
+
+// filterKind determines how values of a filter are validated
+type filterKind int
+
+const (
+	filterString filterKind = iota
+	filterNumber
+	filterNumberRange
+	filterDateRange
+)
+
+// ruleFilters maps fields the rule listing can be filtered by to kind of
+// their values
+var ruleFilters = map[string]filterKind{
+	RuleFieldTag:         filterString,
+	RuleFieldGroup:       filterString,
+	RuleFieldImpact:      filterNumber,
+	RuleFieldLikelihood:  filterNumber,
+	RuleFieldTotalRisk:   filterNumberRange,
+	RuleFieldStatus:      filterString,
+	RuleFieldPublishDate: filterDateRange,
+	RuleFieldProductCode: filterString,
+}
+
+// Range limits values of a field, empty bound means the range is not
+// limited from that side. Both bounds are inclusive.
+type Range struct {
+	From string
+	To   string
+}
+
+// SortKey is one field the listing is sorted by
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// ListQuery describes filtering, sorting and pagination of a listing.
+// Item matches the query when it matches all filters, a filter with several
+// values matches items having any of them.
+type ListQuery struct {
+	Filters map[string][]string
+	Ranges  map[string]Range
+	Sort    []SortKey
+	Limit   int
+	Offset  int
+}
+
+// PageLinks contains links to other pages of a listing
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+}
+
+// ParseRuleListQuery parses filters, sorting and pagination of rule listing
+// from query parameters. Filters are passed as parameters named by the
+// fields, range filters accept either single value or range from..to with
+// optional bounds and can be passed only once. Sort parameter is comma
+// separated list of fields, field prefixed by minus sign is sorted in
+// descending order.
+func (server *HTTPServer) ParseRuleListQuery(r *http.Request) (ListQuery, error) {
+	values := r.URL.Query()
+
+	query := ListQuery{
+		Filters: map[string][]string{},
+		Ranges:  map[string]Range{},
+	}
+
+	for field, kind := range ruleFilters {
+		for _, value := range values[field] {
+			err := addFilter(&query, field, kind, value)
+			if err != nil {
+				return ListQuery{}, err
+			}
+		}
+	}
+
+	if sortValue := values.Get(SortParameter); sortValue != "" {
+		for _, field := range strings.Split(sortValue, ",") {
+			key := SortKey{Field: strings.TrimPrefix(field, "-"), Descending: strings.HasPrefix(field, "-")}
+			if _, found := ruleFilters[key.Field]; !found {
+				return ListQuery{}, &ParameterError{paramName: SortParameter, paramValue: sortValue,
+					errString: "unknown field '" + key.Field + "'"}
+			}
+			query.Sort = append(query.Sort, key)
+		}
+	}
+
+	limit, err := parseLimit(values, DefaultListLimit)
+	if err != nil {
+		return ListQuery{}, err
+	}
+	query.Limit = limit
+
+	if next := values.Get(NextParameter); next != "" {
+		offset, err := parseCursor(next, server.ContentStatus().key(), query.key())
+		if err != nil {
+			return ListQuery{}, &ParameterError{paramName: NextParameter, paramValue: next, errString: err.Error()}
+		}
+		query.Offset = offset
+	}
+
+	return query, nil
+}
+
+// parseLimit parses limit query parameter, default limit is returned when
+// the parameter is not set
+func parseLimit(values url.Values, defaultLimit int) (int, error) {
+	limit := values.Get(LimitParameter)
+	if limit == "" {
+		return defaultLimit, nil
+	}
+
+	parsed, err := strconv.Atoi(limit)
+	if err != nil || parsed < 1 || parsed > MaxListLimit {
+		return 0, &ParameterError{paramName: LimitParameter, paramValue: limit,
+			errString: "limit must be between 1 and " + strconv.Itoa(MaxListLimit)}
+	}
+
+	return parsed, nil
+}
+
+// addFilter validates value of a filter and adds it to the query
+func addFilter(query *ListQuery, field string, kind filterKind, value string) error {
+	invalid := func(message string) error {
+		return &ParameterError{paramName: field, paramValue: value, errString: message}
+	}
+
+	switch kind {
+	case filterNumber:
+		if _, err := strconv.Atoi(value); err != nil {
+			return invalid("value must be a number")
+		}
+	case filterNumberRange, filterDateRange:
+		bounds := Range{From: value, To: value}
+		if parts := strings.SplitN(value, rangeSeparator, 2); len(parts) == 2 {
+			bounds = Range{From: parts[0], To: parts[1]}
+		}
+
+		for _, bound := range []string{bounds.From, bounds.To} {
+			if bound == "" {
+				continue
+			}
+			if kind == filterNumberRange {
+				if _, err := strconv.Atoi(bound); err != nil {
+					return invalid("range bounds must be numbers")
+				}
+			} else if _, err := time.Parse(dateFormat, bound); err != nil {
+				return invalid("range bounds must be dates in format YYYY-MM-DD")
+			}
+		}
+
+		if _, found := query.Ranges[field]; found {
+			return invalid("range can be specified only once")
+		}
+
+		query.Ranges[field] = bounds
+		return nil
+	}
+
+	query.Filters[field] = append(query.Filters[field], value)
+	return nil
+}
+
+// key identifies filters and sorting of the query, it does not depend on
+// order of the filters and their values
+func (query ListQuery) key() string {
+	hash := sha256.New()
+
+	fields := make([]string, 0, len(query.Filters))
+	for field := range query.Filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		values := append([]string{}, query.Filters[field]...)
+		sort.Strings(values)
+		_, _ = fmt.Fprintf(hash, "filter %q %q\n", field, values)
+	}
+
+	fields = fields[:0]
+	for field := range query.Ranges {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		bounds := query.Ranges[field]
+		_, _ = fmt.Fprintf(hash, "range %q %q %q\n", field, bounds.From, bounds.To)
+	}
+
+	for _, key := range query.Sort {
+		_, _ = fmt.Fprintf(hash, "sort %q %t\n", key.Field, key.Descending)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// Page returns bounds of the requested page in listing of total items
+func (query ListQuery) Page(total int) (start, end int) {
+	start = query.Offset
+	if start > total {
+		start = total
+	}
+
+	end = start + query.Limit
+	if end > total {
+		end = total
+	}
+
+	return start, end
+}
+
+// NextPageLink returns link to the page following the requested one, empty
+// string is returned for the last page. The cursor in the link is valid only
+// until new content is loaded and only with the same filters and sorting.
+func (server *HTTPServer) NextPageLink(r *http.Request, query ListQuery, total int) string {
+	_, end := query.Page(total)
+	if end >= total {
+		return ""
+	}
+
+	values := r.URL.Query()
+	values.Set(NextParameter, formatCursor(end, server.ContentStatus().key(), query.key()))
+
+	link := *r.URL
+	link.RawQuery = values.Encode()
+	return link.RequestURI()
+}
+
+// formatCursor encodes offset of the next page together with keys of the
+// query and of the content the listing was made from
+func formatCursor(offset int, contentKey, queryKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset) + "\x00" + queryKey + "\x00" + contentKey))
+}
+
+// parseCursor decodes offset of the next page, the cursor is rejected when
+// the content has changed since the previous page was sent or when it was
+// made for different filters or sorting
+func parseCursor(cursor, contentKey, queryKey string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errMalformedCursor
+	}
+
+	// content key is the last part, it contains the separator itself
+	parts := strings.SplitN(string(decoded), "\x00", 3)
+	if len(parts) != 3 {
+		return 0, errMalformedCursor
+	}
+
+	offset, err := strconv.Atoi(parts[0])
+	if err != nil || offset < 0 {
+		return 0, errMalformedCursor
+	}
+
+	if parts[1] != queryKey {
+		return 0, errCursorMismatch
+	}
+
+	if parts[2] != contentKey {
+		return 0, errStaleCursor
+	}
+
+	return offset, nil
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+func rulesRequest(query string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, config.APIPrefix+"rules"+query, nil)
+}
+
+func TestParseRuleListQueryDefaults(t *testing.T) {
+	testServer := server.New(config)
+
+	query, err := testServer.ParseRuleListQuery(rulesRequest(""))
+
+	assert.NoError(t, err)
+	assert.Empty(t, query.Filters)
+	assert.Empty(t, query.Ranges)
+	assert.Empty(t, query.Sort)
+	assert.Equal(t, server.DefaultListLimit, query.Limit)
+	assert.Equal(t, 0, query.Offset)
+}
+
+func TestParseRuleListQueryFilters(t *testing.T) {
+	testServer := server.New(config)
+
+	query, err := testServer.ParseRuleListQuery(rulesRequest(
+		"?tag=openshift&tag=security&impact=2&total_risk=2..4&publish_date=2020-01-01..&product_code=OCP"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		server.RuleFieldTag:         {"openshift", "security"},
+		server.RuleFieldImpact:      {"2"},
+		server.RuleFieldProductCode: {"OCP"},
+	}, query.Filters)
+	assert.Equal(t, map[string]server.Range{
+		server.RuleFieldTotalRisk:   {From: "2", To: "4"},
+		server.RuleFieldPublishDate: {From: "2020-01-01"},
+	}, query.Ranges)
+}
+
+func TestParseRuleListQuerySingleValueRange(t *testing.T) {
+	testServer := server.New(config)
+
+	query, err := testServer.ParseRuleListQuery(rulesRequest("?total_risk=3"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, server.Range{From: "3", To: "3"}, query.Ranges[server.RuleFieldTotalRisk])
+}
+
+func TestParseRuleListQuerySort(t *testing.T) {
+	testServer := server.New(config)
+
+	query, err := testServer.ParseRuleListQuery(rulesRequest("?sort=-total_risk,publish_date&limit=10"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []server.SortKey{
+		{Field: server.RuleFieldTotalRisk, Descending: true},
+		{Field: server.RuleFieldPublishDate},
+	}, query.Sort)
+	assert.Equal(t, 10, query.Limit)
+}
+
+func TestParseRuleListQueryInvalid(t *testing.T) {
+	testServer := server.New(config)
+
+	for _, query := range []string{
+		"?impact=high",
+		"?total_risk=1..x",
+		"?publish_date=2020-13-01",
+		"?total_risk=1..2&total_risk=3..4",
+		"?sort=summary",
+		"?limit=0",
+		"?limit=1001",
+		"?next=%21%21",
+		"?next=Zm9v",
+	} {
+		_, err := testServer.ParseRuleListQuery(rulesRequest(query))
+		assert.IsType(t, &server.ParameterError{}, err, query)
+	}
+}
+
+func TestPage(t *testing.T) {
+	query := server.ListQuery{Limit: 10, Offset: 20}
+
+	start, end := query.Page(25)
+	assert.Equal(t, 20, start)
+	assert.Equal(t, 25, end)
+
+	start, end = query.Page(100)
+	assert.Equal(t, 20, start)
+	assert.Equal(t, 30, end)
+
+	start, end = query.Page(5)
+	assert.Equal(t, 5, start)
+	assert.Equal(t, 5, end)
+}
+
+func TestNextPageLink(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	req := rulesRequest("?tag=openshift&limit=2")
+	query, err := testServer.ParseRuleListQuery(req)
+	assert.NoError(t, err)
+
+	next := testServer.NextPageLink(req, query, 5)
+	assert.Contains(t, next, config.APIPrefix+"rules?")
+	assert.Contains(t, next, "tag=openshift")
+
+	// follow the links until the last page
+	offsets := []int{query.Offset}
+	for next != "" {
+		req = httptest.NewRequest(http.MethodGet, next, nil)
+		query, err = testServer.ParseRuleListQuery(req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"openshift"}, query.Filters[server.RuleFieldTag])
+
+		offsets = append(offsets, query.Offset)
+		next = testServer.NextPageLink(req, query, 5)
+	}
+
+	assert.Equal(t, []int{0, 2, 4}, offsets)
+}
+
+func TestNextPageLinkStaleCursor(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	req := rulesRequest("?limit=2")
+	query, err := testServer.ParseRuleListQuery(req)
+	assert.NoError(t, err)
+	next := testServer.NextPageLink(req, query, 5)
+
+	// new content invalidates cursors of listings made from the old one
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	req = httptest.NewRequest(http.MethodGet, next, nil)
+	_, err = testServer.ParseRuleListQuery(req)
+	assert.EqualError(t, err, "Invalid value '"+req.URL.Query().Get(server.NextParameter)+
+		"' of parameter 'next': content has changed since the listing was started")
+}
+
+func TestNextPageLinkOtherQuery(t *testing.T) {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	req := rulesRequest("?tag=openshift&tag=security&sort=-impact&limit=2")
+	query, err := testServer.ParseRuleListQuery(req)
+	assert.NoError(t, err)
+	next := testServer.NextPageLink(req, query, 5)
+	cursor := httptest.NewRequest(http.MethodGet, next, nil).URL.Query().Get(server.NextParameter)
+
+	// order of filter values and limit do not matter
+	_, err = testServer.ParseRuleListQuery(rulesRequest("?tag=security&tag=openshift&sort=-impact&limit=3&next=" + cursor))
+	assert.NoError(t, err)
+
+	for _, query := range []string{
+		"?tag=openshift&sort=-impact&next=",
+		"?tag=openshift&tag=security&sort=impact&next=",
+		"?tag=openshift&tag=security&sort=-impact&total_risk=1..&next=",
+	} {
+		_, err = testServer.ParseRuleListQuery(rulesRequest(query + cursor))
+		assert.EqualError(t, err, "Invalid value '"+cursor+
+			"' of parameter 'next': cursor belongs to listing with different filters or sorting", query)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RedHatInsights/insights-content-service/metrics"
+)
+
+const (
+	// UnmatchedEndpoint is the endpoint label of requests that do not match
+	// any route, i.e. the ones rejected with 404 or 405 by the router
+	UnmatchedEndpoint = "unmatched"
+
+	// ContextKeyRoute is a constant for route matched by the router in
+	// request context
+	ContextKeyRoute = contextKey("route")
+)
+
+// matchedRoute is filled by the router for middlewares that wrap it
+type matchedRoute struct {
+	endpoint string
+	matched  bool
+}
+
+// statusWriter remembers status code and size of the response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.length += n
+	return n, err
+}
+
+// Status returns status code of the response
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush sends any buffered data to the client
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Instrumentation middleware updates Prometheus metrics related to API
+// endpoints, it wraps the whole router so requests not matching any route
+// are counted under UnmatchedEndpoint
+func (server *HTTPServer) Instrumentation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := &matchedRoute{}
+		ctx := context.WithValue(r.Context(), ContextKeyRoute, route)
+
+		metrics.APIRequestsInFlight.Inc()
+		defer metrics.APIRequestsInFlight.Dec()
+
+		start := time.Now()
+		writer := &statusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(writer, r.WithContext(ctx))
+
+		endpoint := UnmatchedEndpoint
+		if route.matched {
+			endpoint = route.endpoint
+		}
+
+		metrics.APIRequestsResponseTime.
+			WithLabelValues(endpoint, r.Method).
+			Observe(time.Since(start).Seconds())
+		metrics.APIEndpointsRequests.
+			WithLabelValues(endpoint, r.Method, strconv.Itoa(writer.Status())).
+			Inc()
+	})
+}
+
+// RecordRoute middleware stores route matched by the router for the
+// middlewares wrapping the router and adds it to the request logger and to
+// the request span
+func (server *HTTPServer) RecordRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint, matched := server.endpointName(r)
+		if route, ok := r.Context().Value(ContextKeyRoute).(*matchedRoute); ok {
+			route.endpoint, route.matched = endpoint, matched
+		}
+		recordSpanRoute(r)
+
+		logger := requestLogger(r).With().Str("route", endpoint).Logger()
+		ctx := logger.WithContext(r.Context())
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/metrics"
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.MetricsEndpoint, nil)
+
+	rr := executeRequest(server.New(config), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "api_endpoints_requests_in_flight")
+}
+
+func TestMetricsEndpointWithoutAuth(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+server.MetricsEndpoint, nil)
+
+	rr := executeRequest(server.New(authConfig()), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestInstrumentationCountsRequests(t *testing.T) {
+	counter := metrics.APIEndpointsRequests.WithLabelValues(server.MainEndpoint, http.MethodGet, "200")
+	before := testutil.ToFloat64(counter)
+
+	executeRequest(server.New(config), httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.APIRequestsInFlight))
+}
+
+func TestInstrumentationCountsRejectedRequests(t *testing.T) {
+	counter := metrics.APIEndpointsRequests.WithLabelValues(server.MainEndpoint, http.MethodGet, "403")
+	before := testutil.ToFloat64(counter)
+
+	executeRequest(server.New(authConfig()), httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestInstrumentationCountsUnmatchedRequests(t *testing.T) {
+	counter := metrics.APIEndpointsRequests.WithLabelValues(server.UnmatchedEndpoint, http.MethodGet, "404")
+	before := testutil.ToFloat64(counter)
+
+	executeRequest(server.New(config), httptest.NewRequest(http.MethodGet, config.APIPrefix+"nonexistent", nil))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestInstrumentationCountsUnsupportedMethods(t *testing.T) {
+	counter := metrics.APIEndpointsRequests.WithLabelValues(server.UnmatchedEndpoint, http.MethodDelete, "405")
+	before := testutil.ToFloat64(counter)
+
+	executeRequest(server.New(config), httptest.NewRequest(http.MethodDelete, config.APIPrefix, nil))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
@@ -0,0 +1,178 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/RedHatInsights/insights-content-service/types"
+)
+
+const (
+	// limiterIdleTimeout is time after which unused token bucket is forgotten
+	limiterIdleTimeout = 10 * time.Minute
+	// limiterSweepInterval is how often unused token buckets are looked for
+	limiterSweepInterval = time.Minute
+)
+
+// RateLimitError happens when the caller exceeds its rate limit
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("Rate limit exceeded, retry after %v", e.RetryAfter)
+}
+
+// bucket is a token bucket of one caller
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter keeps token buckets of all callers
+type rateLimiter struct {
+	config    RateLimitConfiguration
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(config RateLimitConfiguration) *rateLimiter {
+	return &rateLimiter{
+		config:    config,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// limit returns rate and burst configured for given organization
+func (limiter *rateLimiter) limit(orgID types.OrgID) (rate.Limit, int) {
+	if orgID != 0 {
+		for _, orgLimit := range limiter.config.Orgs {
+			if orgLimit.OrgID == orgID {
+				return rate.Limit(orgLimit.RequestsPerSecond), burst(orgLimit.RequestsPerSecond, orgLimit.Burst)
+			}
+		}
+	}
+	return rate.Limit(limiter.config.RequestsPerSecond), burst(limiter.config.RequestsPerSecond, limiter.config.Burst)
+}
+
+// burst returns configured burst or, when it is not set, the number of
+// requests allowed per second (at least one). Token bucket with zero burst
+// would reject all requests.
+func burst(requestsPerSecond float64, configured int) int {
+	if configured > 0 {
+		return configured
+	}
+	if requestsPerSecond > 1 {
+		return int(math.Ceil(requestsPerSecond))
+	}
+	return 1
+}
+
+// reserve takes one token from bucket identified by key and returns the
+// time the caller has to wait when the bucket is empty
+func (limiter *rateLimiter) reserve(key string, orgID types.OrgID) (time.Duration, bool) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := time.Now()
+	limiter.sweep(now)
+
+	b, found := limiter.buckets[key]
+	if !found {
+		limit, burst := limiter.limit(orgID)
+		b = &bucket{limiter: rate.NewLimiter(limit, burst)}
+		limiter.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+
+	return 0, true
+}
+
+// sweep forgets token buckets that have not been used for a long time,
+// these are full anyway
+func (limiter *rateLimiter) sweep(now time.Time) {
+	if now.Sub(limiter.lastSweep) < limiterSweepInterval {
+		return
+	}
+	limiter.lastSweep = now
+
+	for key, b := range limiter.buckets {
+		if now.Sub(b.lastSeen) > limiterIdleTimeout {
+			delete(limiter.buckets, key)
+		}
+	}
+}
+
+// rateLimitKey returns key of the token bucket for given request together
+// with the organization ID of the caller
+func (server *HTTPServer) rateLimitKey(r *http.Request) (string, types.OrgID) {
+	identity, err := server.GetCurrentIdentity(r)
+	if err == nil {
+		if identity.Internal.OrgID != 0 {
+			return fmt.Sprintf("org:%d", identity.Internal.OrgID), identity.Internal.OrgID
+		}
+		// usernames of services are prefixed by the type of credentials
+		if identity.Type == ServiceIdentityType {
+			return identity.User.Username, 0
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host, 0
+}
+
+// RateLimiting middleware rejects requests of callers exceeding their rate
+// limit. Token buckets are shared by all handlers the middleware wraps.
+// Requests of exempted URLs, health probes for example, are never limited.
+func (server *HTTPServer) RateLimiting(next http.Handler, exemptURLs []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if stringInSlice(r.RequestURI, exemptURLs) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, orgID := server.rateLimitKey(r)
+
+		retryAfter, allowed := server.rateLimiter.reserve(key, orgID)
+		if !allowed {
+			handleServerError(w, r, &RateLimitError{RetryAfter: retryAfter})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
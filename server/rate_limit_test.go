@@ -0,0 +1,149 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+	"github.com/RedHatInsights/insights-content-service/types"
+)
+
+func rateLimitedConfig() server.Configuration {
+	cfg := authConfig()
+	cfg.RateLimit = server.RateLimitConfiguration{
+		Enabled:           true,
+		RequestsPerSecond: 0.001,
+		Burst:             2,
+		Orgs: []server.OrgRateLimit{
+			{OrgID: 42, RequestsPerSecond: 0.001, Burst: 3},
+		},
+	}
+	return cfg
+}
+
+func orgRequest(t *testing.T, orgID types.OrgID) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{
+		AccountNumber: "1",
+		Internal:      server.Internal{OrgID: orgID},
+	}))
+	return req
+}
+
+// countAllowed sends given number of requests made by makeRequest and returns
+// number of requests that were not rate limited
+func countAllowed(router http.Handler, requests int, makeRequest func() *http.Request) int {
+	allowed := 0
+	for i := 0; i < requests; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, makeRequest())
+		if rr.Code == http.StatusOK {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestRateLimitDefault(t *testing.T) {
+	router := server.New(rateLimitedConfig()).Initialize()
+
+	allowed := countAllowed(router, 5, func() *http.Request { return orgRequest(t, 1) })
+
+	assert.Equal(t, 2, allowed)
+}
+
+func TestRateLimitPerOrg(t *testing.T) {
+	router := server.New(rateLimitedConfig()).Initialize()
+
+	allowed := countAllowed(router, 5, func() *http.Request { return orgRequest(t, 42) })
+
+	assert.Equal(t, 3, allowed)
+}
+
+func TestRateLimitOrgsAreIndependent(t *testing.T) {
+	router := server.New(rateLimitedConfig()).Initialize()
+
+	countAllowed(router, 5, func() *http.Request { return orgRequest(t, 1) })
+	allowed := countAllowed(router, 1, func() *http.Request { return orgRequest(t, 2) })
+
+	assert.Equal(t, 1, allowed)
+}
+
+func TestRateLimitByRemoteAddress(t *testing.T) {
+	cfg := rateLimitedConfig()
+	cfg.Auth = false
+	router := server.New(cfg).Initialize()
+
+	requestFrom := func(remoteAddr string) func() *http.Request {
+		return func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+			req.RemoteAddr = remoteAddr
+			return req
+		}
+	}
+
+	assert.Equal(t, 2, countAllowed(router, 5, requestFrom("10.0.0.1:1000")))
+	assert.Equal(t, 0, countAllowed(router, 1, requestFrom("10.0.0.1:2000")))
+	assert.Equal(t, 1, countAllowed(router, 1, requestFrom("10.0.0.2:1000")))
+}
+
+func TestRateLimitResponse(t *testing.T) {
+	router := server.New(rateLimitedConfig()).Initialize()
+	countAllowed(router, 2, func() *http.Request { return orgRequest(t, 1) })
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, orgRequest(t, 1))
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	assert.Contains(t, rr.Body.String(), "Rate limit exceeded")
+}
+
+func TestRateLimitDefaultBurst(t *testing.T) {
+	cfg := rateLimitedConfig()
+	cfg.RateLimit.Burst = 0
+	cfg.RateLimit.Orgs = []server.OrgRateLimit{{OrgID: 42, RequestsPerSecond: 3.5}}
+	router := server.New(cfg).Initialize()
+
+	// burst is one request at least
+	assert.Equal(t, 1, countAllowed(router, 5, func() *http.Request { return orgRequest(t, 1) }))
+	// or number of requests per second rounded up
+	assert.Equal(t, 4, countAllowed(router, 5, func() *http.Request { return orgRequest(t, 42) }))
+}
+
+func TestRateLimitProbesAndMetricsExempted(t *testing.T) {
+	cfg := rateLimitedConfig()
+	cfg.Auth = false
+	router := server.New(cfg).Initialize()
+
+	for _, path := range []string{server.LivenessEndpoint, server.ReadinessEndpoint, config.APIPrefix + server.MetricsEndpoint} {
+		requests := 0
+		for i := 0; i < 5; i++ {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+			if rr.Code != http.StatusTooManyRequests {
+				requests++
+			}
+		}
+		assert.Equal(t, 5, requests, path)
+	}
+}
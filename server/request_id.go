@@ -0,0 +1,112 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// RequestIDHeader is the name of HTTP header with ID of the request
+	RequestIDHeader = "X-Request-ID"
+
+	// ContextKeyRequestID is a constant for request ID in request context
+	ContextKeyRequestID = contextKey("request_id")
+
+	// maxRequestIDLength limits length of request IDs accepted from callers
+	maxRequestIDLength = 128
+)
+
+// RequestID middleware accepts request ID sent by caller or generates a new
+// one, echoes it in the response and attaches logger with the request ID
+// to the request context. It wraps the whole router, so responses to
+// requests not matching any route carry the request ID too.
+func (server *HTTPServer) RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		logger := log.Logger.With().
+			Str("request_id", requestID).
+			Logger()
+
+		ctx := context.WithValue(r.Context(), ContextKeyRequestID, requestID)
+		ctx = logger.WithContext(ctx)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestID retrieves ID of the request, it is empty for requests that
+// have not passed RequestID middleware
+func GetRequestID(request *http.Request) string {
+	requestID, _ := request.Context().Value(ContextKeyRequestID).(string)
+	return requestID
+}
+
+// requestLogger returns logger attached to the request context or the
+// global logger for requests that have not passed RequestID middleware
+func requestLogger(request *http.Request) *zerolog.Logger {
+	if request == nil {
+		return &log.Logger
+	}
+
+	logger := zerolog.Ctx(request.Context())
+	if logger.GetLevel() == zerolog.Disabled {
+		return &log.Logger
+	}
+
+	return logger
+}
+
+// isValidRequestID checks that request ID sent by caller is safe to be used
+// in logs and response headers
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID generates random UUID (version 4) used as request ID
+func newRequestID() string {
+	uuid := make([]byte, 16)
+
+	// crypto/rand reader does not fail on supported platforms
+	_, _ = rand.Read(uuid)
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+// captureLog redirects global logger into buffer until the returned function is called
+func captureLog() (*bytes.Buffer, func()) {
+	var output bytes.Buffer
+	originalLogger := log.Logger
+	log.Logger = zerolog.New(&output)
+
+	return &output, func() { log.Logger = originalLogger }
+}
+
+func TestRequestIDEchoed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set(server.RequestIDHeader, "request-1")
+
+	rr := executeRequest(server.New(config), req)
+
+	assert.Equal(t, "request-1", rr.Header().Get(server.RequestIDHeader))
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+
+	rr := executeRequest(server.New(config), req)
+
+	assert.Regexp(t,
+		"^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$",
+		rr.Header().Get(server.RequestIDHeader))
+}
+
+func TestRequestIDInvalidReplaced(t *testing.T) {
+	for _, requestID := range []string{"contains space", strings.Repeat("x", 129)} {
+		req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+		req.Header.Set(server.RequestIDHeader, requestID)
+
+		rr := executeRequest(server.New(config), req)
+
+		assert.NotEqual(t, requestID, rr.Header().Get(server.RequestIDHeader))
+		assert.NotEmpty(t, rr.Header().Get(server.RequestIDHeader))
+	}
+}
+
+func TestRequestIDEchoedForUnmatchedRequests(t *testing.T) {
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, config.APIPrefix+"nonexistent", nil),
+		httptest.NewRequest(http.MethodDelete, config.APIPrefix, nil),
+	}
+
+	for _, req := range requests {
+		req.Header.Set(server.RequestIDHeader, "request-1")
+
+		rr := executeRequest(server.New(config), req)
+
+		assert.NotEqual(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "request-1", rr.Header().Get(server.RequestIDHeader))
+	}
+}
+
+func TestRequestLoggerUsedForErrors(t *testing.T) {
+	output, restore := captureLog()
+	defer restore()
+
+	router := server.New(rateLimitedConfig()).Initialize()
+	countAllowed(router, 2, func() *http.Request { return orgRequest(t, 7) })
+
+	req := orgRequest(t, 7)
+	req.Header.Set(server.RequestIDHeader, "request-2")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+	// the last message is logged when the request is rejected
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	event := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &event))
+	assert.Equal(t, "request-2", event["request_id"])
+	assert.Equal(t, server.MainEndpoint, event["route"])
+	assert.Equal(t, float64(7), event["org_id"])
+	assert.Equal(t, "1", event["account"])
+}
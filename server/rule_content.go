@@ -0,0 +1,135 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// MaxRuleContentKeys is the largest number of error keys that can be
+	// looked up by one request
+	MaxRuleContentKeys = 1000
+
+	// maxRuleContentBodySize limits size of bulk lookup request body
+	maxRuleContentBodySize = 1 << 20
+)
+
+// RuleErrorKey identifies content of one error key of a rule
+type RuleErrorKey struct {
+	RuleID   string `json:"rule_id"`
+	ErrorKey string `json:"error_key"`
+}
+
+// ruleErrorKeyContent is content of one error key found by bulk lookup
+type ruleErrorKeyContent struct {
+	RuleID   string      `json:"rule_id"`
+	ErrorKey string      `json:"error_key"`
+	Content  interface{} `json:"content"`
+}
+
+// ruleContentResponse is the body of bulk lookup endpoint response, error
+// keys that do not exist are listed separately instead of failing the
+// whole request
+type ruleContentResponse struct {
+	Status   string                `json:"status"`
+	Content  []ruleErrorKeyContent `json:"content"`
+	NotFound []RuleErrorKey        `json:"not_found"`
+}
+
+// readRuleErrorKeys reads list of error keys from request body, duplicate
+// keys are dropped
+func readRuleErrorKeys(writer http.ResponseWriter, request *http.Request) ([]RuleErrorKey, error) {
+	var keys []RuleErrorKey
+
+	body := http.MaxBytesReader(writer, request.Body, maxRuleContentBodySize)
+	err := json.NewDecoder(body).Decode(&keys)
+	if err != nil {
+		return nil, &RequestBodyError{errString: "Request body must be a JSON list of rule ID and error key pairs"}
+	}
+
+	if len(keys) > MaxRuleContentKeys {
+		return nil, &RequestBodyError{errString: "At most " + strconv.Itoa(MaxRuleContentKeys) + " error keys can be requested at once"}
+	}
+
+	unique := make([]RuleErrorKey, 0, len(keys))
+	seen := map[RuleErrorKey]bool{}
+	for _, key := range keys {
+		if key.RuleID == "" || key.ErrorKey == "" {
+			return nil, &RequestBodyError{errString: "Both rule_id and error_key have to be specified"}
+		}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+
+	return unique, nil
+}
+
+// ruleContentEndpoint returns content of several rule error keys at once,
+// the content is limited by fields query parameter
+func (server *HTTPServer) ruleContentEndpoint(writer http.ResponseWriter, request *http.Request) {
+	fields, err := requestedFields(request)
+	if err != nil {
+		handleServerError(writer, request, err)
+		return
+	}
+
+	keys, err := readRuleErrorKeys(writer, request)
+	if err != nil {
+		handleServerError(writer, request, err)
+		return
+	}
+
+	response := ruleContentResponse{
+		Status:   "ok",
+		Content:  []ruleErrorKeyContent{},
+		NotFound: []RuleErrorKey{},
+	}
+
+	for _, key := range keys {
+		var content interface{}
+		found := false
+		if server.RuleContent != nil {
+			content, found = server.RuleContent(key.RuleID, key.ErrorKey)
+		}
+
+		if !found {
+			response.NotFound = append(response.NotFound, key)
+			continue
+		}
+
+		if fields != nil {
+			content, err = fields.apply(content)
+			if err != nil {
+				handleServerError(writer, request, err)
+				return
+			}
+		}
+
+		response.Content = append(response.Content, ruleErrorKeyContent{
+			RuleID:   key.RuleID,
+			ErrorKey: key.ErrorKey,
+			Content:  content,
+		})
+	}
+
+	server.SendContent(writer, request, response)
+}
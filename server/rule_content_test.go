@@ -0,0 +1,126 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+type ruleContentResponse struct {
+	Status  string `json:"status"`
+	Content []struct {
+		RuleID   string                 `json:"rule_id"`
+		ErrorKey string                 `json:"error_key"`
+		Content  map[string]interface{} `json:"content"`
+	} `json:"content"`
+	NotFound []server.RuleErrorKey `json:"not_found"`
+}
+
+// ruleContentServer serves content of SLOW_DISK error key of etcd rule only
+func ruleContentServer() *server.HTTPServer {
+	testServer := server.New(config)
+	testServer.RuleContent = func(ruleID, errorKey string) (interface{}, bool) {
+		if ruleID == "etcd_disk_latency" && errorKey == "SLOW_DISK" {
+			return selectedRule, true
+		}
+		return nil, false
+	}
+	return testServer
+}
+
+func ruleContentRequest(query, body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, config.APIPrefix+server.RuleContentEndpoint+query, strings.NewReader(body))
+}
+
+func TestRuleContentEndpoint(t *testing.T) {
+	rr := executeRequest(ruleContentServer(), ruleContentRequest("", `[
+		{"rule_id": "etcd_disk_latency", "error_key": "SLOW_DISK"},
+		{"rule_id": "etcd_disk_latency", "error_key": "SLOW_FSYNC"},
+		{"rule_id": "etcd_disk_latency", "error_key": "SLOW_DISK"}
+	]`))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response ruleContentResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, "ok", response.Status)
+	assert.Len(t, response.Content, 1)
+	assert.Equal(t, "etcd_disk_latency", response.Content[0].RuleID)
+	assert.Equal(t, "SLOW_DISK", response.Content[0].ErrorKey)
+	assert.Equal(t, selectedRule.Summary, response.Content[0].Content["summary"])
+	assert.Equal(t, []server.RuleErrorKey{{RuleID: "etcd_disk_latency", ErrorKey: "SLOW_FSYNC"}}, response.NotFound)
+}
+
+func TestRuleContentEndpointFields(t *testing.T) {
+	rr := executeRequest(ruleContentServer(), ruleContentRequest("?fields=summary,tags",
+		`[{"rule_id": "etcd_disk_latency", "error_key": "SLOW_DISK"}]`))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response ruleContentResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, map[string]interface{}{
+		"summary": selectedRule.Summary,
+		"tags":    []interface{}{"etcd", "performance"},
+	}, response.Content[0].Content)
+}
+
+func TestRuleContentEndpointWithoutContent(t *testing.T) {
+	rr := executeRequest(server.New(config), ruleContentRequest("",
+		`[{"rule_id": "etcd_disk_latency", "error_key": "SLOW_DISK"}]`))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status": "ok", "content": [],
+		"not_found": [{"rule_id": "etcd_disk_latency", "error_key": "SLOW_DISK"}]}`, rr.Body.String())
+}
+
+func TestRuleContentEndpointInvalidRequest(t *testing.T) {
+	tooMany := "[" + strings.Repeat(`{"rule_id": "r", "error_key": "k"},`, server.MaxRuleContentKeys) +
+		`{"rule_id": "r", "error_key": "k"}]`
+
+	for _, request := range []*http.Request{
+		ruleContentRequest("", "not JSON"),
+		ruleContentRequest("", `{"rule_id": "etcd_disk_latency", "error_key": "SLOW_DISK"}`),
+		ruleContentRequest("", `[{"rule_id": "etcd_disk_latency"}]`),
+		ruleContentRequest("", tooMany),
+		ruleContentRequest("?fields=summary..text", `[]`),
+	} {
+		rr := executeRequest(ruleContentServer(), request)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, request.URL.String())
+	}
+}
+
+func TestRuleContentEndpointMethod(t *testing.T) {
+	rr := executeRequest(ruleContentServer(),
+		httptest.NewRequest(http.MethodGet, config.APIPrefix+server.RuleContentEndpoint, nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestRuleContentEndpointRequiresAuth(t *testing.T) {
+	rr := executeRequest(server.New(authConfig()), ruleContentRequest("", `[]`))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
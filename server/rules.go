@@ -0,0 +1,215 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/gob"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RuleListItem is one rule error key in rule listing, its fields are used
+// by filters and sorting of the listing
+type RuleListItem struct {
+	RuleID      string    `json:"rule_id"`
+	ErrorKey    string    `json:"error_key"`
+	Summary     string    `json:"summary"`
+	Tags        []string  `json:"tags"`
+	Group       string    `json:"group"`
+	Impact      int       `json:"impact"`
+	Likelihood  int       `json:"likelihood"`
+	TotalRisk   int       `json:"total_risk"`
+	Status      string    `json:"status"`
+	PublishDate time.Time `json:"publish_date"`
+	ProductCode string    `json:"product_code"`
+}
+
+// rulesResponse is the body of rule listing endpoint response, the rules
+// are limited to fields selected by fields query parameter
+type rulesResponse struct {
+	Status string      `json:"status"`
+	Rules  interface{} `json:"rules"`
+	Total  int         `json:"total"`
+	Links  PageLinks   `json:"links"`
+}
+
+func init() {
+	// listed rules are sent as interface value, gob needs to know its type
+	gob.Register([]RuleListItem{})
+}
+
+// SetRuleList replaces rule error keys listed by rules endpoint, it is
+// expected to be called with rules of newly loaded content
+func (server *HTTPServer) SetRuleList(rules []RuleListItem) {
+	server.content.mutex.Lock()
+	defer server.content.mutex.Unlock()
+
+	server.content.rules = rules
+}
+
+func (server *HTTPServer) ruleList() []RuleListItem {
+	server.content.mutex.RLock()
+	defer server.content.mutex.RUnlock()
+
+	return server.content.rules
+}
+
+// rulesEndpoint returns page of rule error keys matching filters of the
+// request, the listing is empty until the rules are set
+func (server *HTTPServer) rulesEndpoint(writer http.ResponseWriter, request *http.Request) {
+	query, err := server.ParseRuleListQuery(request)
+	if err != nil {
+		handleServerError(writer, request, err)
+		return
+	}
+
+	matching := []RuleListItem{}
+	for _, rule := range server.ruleList() {
+		if query.matches(rule) {
+			matching = append(matching, rule)
+		}
+	}
+	query.sortRules(matching)
+
+	start, end := query.Page(len(matching))
+
+	rules, err := server.SelectFields(request, matching[start:end])
+	if err != nil {
+		handleServerError(writer, request, err)
+		return
+	}
+
+	server.SendContent(writer, request, rulesResponse{
+		Status: "ok",
+		Rules:  rules,
+		Total:  len(matching),
+		Links:  PageLinks{Next: server.NextPageLink(request, query, len(matching))},
+	})
+}
+
+// fieldValues returns values of the rule field as used by filters
+func (rule RuleListItem) fieldValues(field string) []string {
+	switch field {
+	case RuleFieldTag:
+		return rule.Tags
+	case RuleFieldGroup:
+		return []string{rule.Group}
+	case RuleFieldImpact:
+		return []string{strconv.Itoa(rule.Impact)}
+	case RuleFieldLikelihood:
+		return []string{strconv.Itoa(rule.Likelihood)}
+	case RuleFieldTotalRisk:
+		return []string{strconv.Itoa(rule.TotalRisk)}
+	case RuleFieldStatus:
+		return []string{rule.Status}
+	case RuleFieldPublishDate:
+		return []string{rule.PublishDate.Format(dateFormat)}
+	case RuleFieldProductCode:
+		return []string{rule.ProductCode}
+	}
+	return nil
+}
+
+// matches checks the rule against all filters and ranges of the query
+func (query ListQuery) matches(rule RuleListItem) bool {
+	for field, wanted := range query.Filters {
+		if !anyValueMatches(rule.fieldValues(field), wanted) {
+			return false
+		}
+	}
+
+	for field, bounds := range query.Ranges {
+		if !rule.inRange(field, bounds) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyValueMatches checks whether any of values is one of wanted values,
+// string values are compared case-insensitively
+func anyValueMatches(values, wanted []string) bool {
+	for _, value := range values {
+		if headerInSlice(value, wanted) {
+			return true
+		}
+	}
+	return false
+}
+
+// inRange checks value of range field against bounds validated by
+// ParseRuleListQuery, dates in YYYY-MM-DD format are compared as strings
+func (rule RuleListItem) inRange(field string, bounds Range) bool {
+	if field == RuleFieldTotalRisk {
+		from, fromErr := strconv.Atoi(bounds.From)
+		to, toErr := strconv.Atoi(bounds.To)
+		return (fromErr != nil || rule.TotalRisk >= from) && (toErr != nil || rule.TotalRisk <= to)
+	}
+
+	value := rule.PublishDate.Format(dateFormat)
+	return (bounds.From == "" || value >= bounds.From) && (bounds.To == "" || value <= bounds.To)
+}
+
+// sortRules sorts rules by sort keys of the query, ties are ordered by rule
+// ID and error key so pages of the listing do not overlap
+func (query ListQuery) sortRules(rules []RuleListItem) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		for _, key := range query.Sort {
+			order := compareRuleField(rules[i], rules[j], key.Field)
+			if key.Descending {
+				order = -order
+			}
+			if order != 0 {
+				return order < 0
+			}
+		}
+
+		if rules[i].RuleID != rules[j].RuleID {
+			return rules[i].RuleID < rules[j].RuleID
+		}
+		return rules[i].ErrorKey < rules[j].ErrorKey
+	})
+}
+
+// compareRuleField returns negative number, zero or positive number when
+// the field of the first rule is less, equal or greater than the second one
+func compareRuleField(a, b RuleListItem, field string) int {
+	switch field {
+	case RuleFieldImpact:
+		return a.Impact - b.Impact
+	case RuleFieldLikelihood:
+		return a.Likelihood - b.Likelihood
+	case RuleFieldTotalRisk:
+		return a.TotalRisk - b.TotalRisk
+	case RuleFieldPublishDate:
+		switch {
+		case a.PublishDate.Before(b.PublishDate):
+			return -1
+		case a.PublishDate.After(b.PublishDate):
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(
+		strings.Join(a.fieldValues(field), ","),
+		strings.Join(b.fieldValues(field), ","))
+}
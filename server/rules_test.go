@@ -0,0 +1,160 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+type rulesResponse struct {
+	Status string                `json:"status"`
+	Rules  []server.RuleListItem `json:"rules"`
+	Total  int                   `json:"total"`
+	Links  server.PageLinks      `json:"links"`
+}
+
+func date(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func rulesServer() *server.HTTPServer {
+	testServer := server.New(config)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+	testServer.SetRuleList([]server.RuleListItem{
+		{RuleID: "etcd_disk_latency", ErrorKey: "SLOW_DISK", Tags: []string{"openshift", "performance"},
+			TotalRisk: 3, Status: "active", PublishDate: date("2020-03-01"), ProductCode: "OCP"},
+		{RuleID: "etcd_disk_latency", ErrorKey: "SLOW_FSYNC", Tags: []string{"openshift"},
+			TotalRisk: 2, Status: "active", PublishDate: date("2020-05-01"), ProductCode: "OCP"},
+		{RuleID: "kubelet_version", ErrorKey: "NODE_KUBELET_VERSION", Tags: []string{"openshift", "security"},
+			TotalRisk: 4, Status: "active", PublishDate: date("2020-01-15"), ProductCode: "OCP"},
+		{RuleID: "sap_hana", ErrorKey: "MEMORY", Tags: []string{"sap"},
+			TotalRisk: 1, Status: "inactive", PublishDate: date("2019-11-01"), ProductCode: "RHEL"},
+	})
+	return testServer
+}
+
+func listRules(t *testing.T, testServer *server.HTTPServer, url string) rulesResponse {
+	rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, url, nil))
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response rulesResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return response
+}
+
+func errorKeys(rules []server.RuleListItem) []string {
+	keys := []string{}
+	for _, rule := range rules {
+		keys = append(keys, rule.ErrorKey)
+	}
+	return keys
+}
+
+func TestRulesEndpoint(t *testing.T) {
+	response := listRules(t, rulesServer(), config.APIPrefix+server.RulesEndpoint)
+
+	assert.Equal(t, "ok", response.Status)
+	assert.Equal(t, 4, response.Total)
+	assert.Equal(t, []string{"SLOW_DISK", "SLOW_FSYNC", "NODE_KUBELET_VERSION", "MEMORY"}, errorKeys(response.Rules))
+	assert.Empty(t, response.Links.Next)
+}
+
+func TestRulesEndpointWithoutRules(t *testing.T) {
+	rr := executeRequest(server.New(config), rulesRequest(""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status": "ok", "rules": [], "total": 0, "links": {}}`, rr.Body.String())
+}
+
+func TestRulesEndpointFiltersAndSorting(t *testing.T) {
+	testServer := rulesServer()
+
+	response := listRules(t, testServer, config.APIPrefix+"rules?tag=openshift&total_risk=3..&sort=-publish_date")
+	assert.Equal(t, []string{"SLOW_DISK", "NODE_KUBELET_VERSION"}, errorKeys(response.Rules))
+
+	response = listRules(t, testServer, config.APIPrefix+"rules?tag=sap&tag=security&sort=total_risk")
+	assert.Equal(t, []string{"MEMORY", "NODE_KUBELET_VERSION"}, errorKeys(response.Rules))
+
+	response = listRules(t, testServer, config.APIPrefix+"rules?publish_date=..2020-02-01&status=active")
+	assert.Equal(t, []string{"NODE_KUBELET_VERSION"}, errorKeys(response.Rules))
+}
+
+func TestRulesEndpointPagination(t *testing.T) {
+	testServer := rulesServer()
+
+	keys := []string{}
+	url := config.APIPrefix + "rules?product_code=OCP&sort=-total_risk&limit=2"
+	for url != "" {
+		response := listRules(t, testServer, url)
+		assert.Equal(t, 3, response.Total)
+
+		keys = append(keys, errorKeys(response.Rules)...)
+		url = response.Links.Next
+	}
+
+	assert.Equal(t, []string{"NODE_KUBELET_VERSION", "SLOW_DISK", "SLOW_FSYNC"}, keys)
+}
+
+func TestRulesEndpointFields(t *testing.T) {
+	rr := executeRequest(rulesServer(), rulesRequest("?product_code=RHEL&fields=rule_id,error_key,total_risk"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"status": "ok",
+		"rules": [{"rule_id": "sap_hana", "error_key": "MEMORY", "total_risk": 1}],
+		"total": 1,
+		"links": {}
+	}`, rr.Body.String())
+}
+
+func TestRulesEndpointInvalidParameters(t *testing.T) {
+	testServer := rulesServer()
+
+	for _, query := range []string{"?impact=high", "?total_risk=1..2&total_risk=3..4", "?sort=summary", "?next=Zm9v", "?fields=rule_id,,tags"} {
+		rr := executeRequest(testServer, rulesRequest(query))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestRulesEndpointRequiresAuth(t *testing.T) {
+	rr := executeRequest(server.New(authConfig()), rulesRequest(""))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestRulesEndpointFormats(t *testing.T) {
+	testServer := rulesServer()
+
+	for _, format := range []string{"json", "yaml", "gob", "msgpack"} {
+		for _, query := range []string{"?format=" + format, "?fields=rule_id&format=" + format} {
+			rr := executeRequest(testServer, rulesRequest(query))
+			assert.Equal(t, http.StatusOK, rr.Code, query)
+		}
+	}
+}
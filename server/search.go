@@ -0,0 +1,78 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/RedHatInsights/insights-content-service/search"
+)
+
+const (
+	// QueryParameter is the name of query parameter with full-text query
+	QueryParameter = "q"
+	// DefaultSearchLimit is the number of search results returned when
+	// limit is not specified
+	DefaultSearchLimit = 20
+)
+
+// searchResponse is the body of search endpoint response
+type searchResponse struct {
+	Status  string          `json:"status"`
+	Results []search.Result `json:"results"`
+}
+
+// SetSearchIndex replaces full-text index used by search endpoint, it is
+// expected to be called with index of newly loaded content
+func (server *HTTPServer) SetSearchIndex(index *search.Index) {
+	server.content.mutex.Lock()
+	defer server.content.mutex.Unlock()
+
+	server.content.index = index
+}
+
+func (server *HTTPServer) searchIndex() *search.Index {
+	server.content.mutex.RLock()
+	defer server.content.mutex.RUnlock()
+
+	return server.content.index
+}
+
+// searchEndpoint returns rules matching full-text query, no rules are found
+// until the content is indexed
+func (server *HTTPServer) searchEndpoint(writer http.ResponseWriter, request *http.Request) {
+	values := request.URL.Query()
+
+	text := values.Get(QueryParameter)
+	if text == "" {
+		handleServerError(writer, request, &ParameterError{paramName: QueryParameter, errString: "query must not be empty"})
+		return
+	}
+
+	limit, err := parseLimit(values, DefaultSearchLimit)
+	if err != nil {
+		handleServerError(writer, request, err)
+		return
+	}
+
+	response := searchResponse{Status: "ok", Results: []search.Result{}}
+	if index := server.searchIndex(); index != nil {
+		response.Results = index.Search(text, limit)
+	}
+
+	server.SendContent(writer, request, response)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/search"
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+type searchResponse struct {
+	Status  string          `json:"status"`
+	Results []search.Result `json:"results"`
+}
+
+func searchRequest(query string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, config.APIPrefix+server.SearchEndpoint+query, nil)
+}
+
+func TestSearchEndpoint(t *testing.T) {
+	testServer := server.New(config)
+	testServer.SetSearchIndex(search.NewIndex([]search.Document{
+		{ID: "etcd_disk_latency|SLOW_DISK", Fields: map[string]string{search.FieldSummary: "Etcd disk latency is high"}},
+		{ID: "kubelet_version|NODE_KUBELET_VERSION", Fields: map[string]string{search.FieldSummary: "Kubelet version mismatch"}},
+	}, search.DefaultBoosts))
+
+	rr := executeRequest(testServer, searchRequest("?q=etcd+latency"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response searchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, "ok", response.Status)
+	assert.Len(t, response.Results, 1)
+	assert.Equal(t, "etcd_disk_latency|SLOW_DISK", response.Results[0].ID)
+	assert.Equal(t, "<em>Etcd</em> disk <em>latency</em> is high", response.Results[0].Highlights[search.FieldSummary])
+}
+
+func TestSearchEndpointLimit(t *testing.T) {
+	testServer := server.New(config)
+	testServer.SetSearchIndex(search.NewIndex([]search.Document{
+		{ID: "a", Fields: map[string]string{search.FieldSummary: "proxy"}},
+		{ID: "b", Fields: map[string]string{search.FieldSummary: "proxy"}},
+	}, nil))
+
+	rr := executeRequest(testServer, searchRequest("?q=proxy&limit=1"))
+
+	var response searchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Len(t, response.Results, 1)
+}
+
+func TestSearchEndpointWithoutIndex(t *testing.T) {
+	rr := executeRequest(server.New(config), searchRequest("?q=etcd"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok","results":[]}`, rr.Body.String())
+}
+
+func TestSearchEndpointInvalidParameters(t *testing.T) {
+	for _, query := range []string{"", "?q=", "?q=etcd&limit=x"} {
+		rr := executeRequest(server.New(config), searchRequest(query))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestSearchEndpointRequiresAuth(t *testing.T) {
+	rr := executeRequest(server.New(authConfig()), searchRequest("?q=etcd"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -17,14 +17,246 @@ limitations under the License.
 // Package server contains implementation of REST API server (HTTPServer) for the
 // Insights content service. In current version, the following
 // REST API endpoints are available:
+//
+// API_PREFIX/ - returns status ok
+//
+// API_PREFIX/openapi.json - OpenAPI specification of the REST API
+//
+// API_PREFIX/metrics - Prometheus metrics
+//
+// API_PREFIX/info - build information and version of the served content
+//
+// API_PREFIX/search?q= - full-text search of rule content
+//
+// API_PREFIX/rules - filtered, sorted and paginated listing of rules
+//
+// API_PREFIX/rules/content - content of several rule error keys at once (POST)
+//
+// API_PREFIX/content/reload - forces reload of the served content (POST), it
+// requires authentication and the content-admin role even when auth. is
+// disabled
+//
+// /health/live - liveness probe
+//
+// /health/ready - readiness probe, reports content version and status of the last content load
+//
+// In debug mode, the following endpoints are available too, they require
+// authentication and the operator role even when auth. is disabled:
+//
+// API_PREFIX/debug/config - effective configuration with secrets redacted
+//
+// API_PREFIX/debug/routes - list of registered routes
+//
+// API_PREFIX/debug/pprof/ - pprof profiles
 package server
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
 // HTTPServer in an implementation of Server interface
 type HTTPServer struct {
-	Config Configuration
-	Serv   *http.Server
+	Config   Configuration
+	APIKeys  []APIKey
+	AuditLog zerolog.Logger
+	Serv     *http.Server
+	// BuildInfo is reported by info endpoint
+	BuildInfo BuildInfo
+	// DebugConfig is returned by debug config endpoint instead of server
+	// configuration, secrets have to be redacted from it
+	DebugConfig interface{}
+	// StorageCheck is called by readiness probe to check that storage
+	// backend is reachable, the check is skipped when it is not set
+	StorageCheck func() error
+	// ContentLoaderEnabled is set when content is loaded into the server
+	// and reported by RecordContentLoad, readiness probe waits for the first
+	// successful load then. The server is ready without content otherwise.
+	ContentLoaderEnabled bool
+	// RuleContent returns content of rule error key for bulk lookup
+	// endpoint, all error keys are reported as not found when it is not set.
+	// Types of the content have to be registered by gob.Register to be
+	// served in gob format.
+	RuleContent func(ruleID, errorKey string) (interface{}, bool)
+	// ContentReload is called by content reload endpoint to reload the
+	// served content, the endpoint reports that reload is not supported
+	// when it is not set
+	ContentReload func() error
+
+	content         contentState
+	bundles         bundleCache
+	draining        chan struct{}
+	drainOnce       sync.Once
+	rateLimiter     *rateLimiter
+	accessLogWriter io.Writer
+}
+
+// New constructs new implementation of Server interface. Audit events are
+// written to standard output unless AuditLog is replaced.
+func New(config Configuration) *HTTPServer {
+	return &HTTPServer{
+		Config:          config,
+		AuditLog:        NewAuditLogger(os.Stdout),
+		Serv:            &http.Server{Addr: config.Address},
+		draining:        make(chan struct{}),
+		accessLogWriter: newAccessLogWriter(config.AccessLog),
+	}
+}
+
+func (server *HTTPServer) mainEndpoint(writer http.ResponseWriter, request *http.Request) {
+	err := responses.SendResponse(writer, responses.BuildOkResponse())
+	if err != nil {
+		requestLogger(request).Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// serveAPISpecFile serves an OpenAPI specifications file specified in config file
+func (server *HTTPServer) serveAPISpecFile(writer http.ResponseWriter, request *http.Request) {
+	absPath, err := filepath.Abs(server.Config.APISpecFile)
+	if err != nil {
+		handleServerError(writer, request, err)
+		return
+	}
+
+	http.ServeFile(writer, request, absPath)
+}
+
+// Initialize perform the server initialization
+func (server *HTTPServer) Initialize() http.Handler {
+	log.Info().Msgf("Initializing HTTP server at '%s'", server.Config.Address)
+
+	router := mux.NewRouter().StrictSlash(true)
+
+	// matched route is recorded for the metrics and the request span
+	// wrapping the router and for the request logger
+	router.Use(server.RecordRoute)
+
+	// compression is done inside the router, so the access log wrapping it
+	// reports size of the compressed responses
+	if server.Config.Compression.Enabled {
+		router.Use(server.Compression)
+	}
+
+	apiPrefix := server.Config.APIPrefix
+	metricsURL := apiPrefix + MetricsEndpoint
+	openAPIURL := apiPrefix + filepath.Base(server.Config.APISpecFile)
+
+	// enable authentication and authorization, but only if it is setup in configuration
+	if server.Config.Auth {
+		// the OpenAPI specification, Prometheus metrics and health probes
+		// have to be available even without auth. token
+		noAuthURLs := []string{
+			metricsURL,
+			openAPIURL,
+			LivenessEndpoint,
+			ReadinessEndpoint,
+			metricsURL + "?", // to be able to test using Frisby
+			openAPIURL + "?", // to be able to test using Frisby
+		}
+		router.Use(func(next http.Handler) http.Handler { return server.Authentication(next, noAuthURLs) })
+		router.Use(func(next http.Handler) http.Handler { return server.Authorization(next, noAuthURLs) })
+	}
+
+	// rate limiting is keyed by organization, so it needs to follow
+	// authentication; probes and metrics scraping are never limited, they
+	// are keyed by remote address shared by all kubelet or Prometheus calls
+	if server.Config.RateLimit.Enabled {
+		noLimitURLs := []string{
+			metricsURL,
+			LivenessEndpoint,
+			ReadinessEndpoint,
+			metricsURL + "?",
+		}
+		server.rateLimiter = newRateLimiter(server.Config.RateLimit)
+		router.Use(func(next http.Handler) http.Handler { return server.RateLimiting(next, noLimitURLs) })
+	}
+
+	// faults are injected into responses of authorized requests only
+	if server.Config.Debug && len(server.Config.Faults) > 0 {
+		router.Use(server.FaultInjection)
+	}
+
+	server.addEndpointsToRouter(router)
+
+	var handler http.Handler = router
+
+	// CORS needs to answer preflight requests before the router rejects
+	// them for unsupported method and before authentication
+	if server.Config.CORS.Enabled {
+		handler = server.CORS(handler)
+	}
+
+	// access log has to include the rejected requests too, including the
+	// ones not matching any route and CORS preflight requests
+	if server.Config.AccessLog.Enabled {
+		handler = server.AccessLog(handler)
+	}
+
+	// request span covers all other middlewares, it is a no-op when tracing
+	// is not configured
+	handler = server.Tracing(handler)
+
+	// metrics are collected for all requests, including the rejected ones
+	// and the ones not matching any route
+	handler = server.Instrumentation(handler)
+
+	// request ID and request logger need to be available in all other
+	// middlewares and in responses to requests not matching any route
+	return server.RequestID(handler)
+}
+
+// Start starts server, it returns nil after the server is stopped
+func (server *HTTPServer) Start() error {
+	address := server.Config.Address
+	log.Info().Msgf("Starting HTTP server at '%s'", address)
+	server.Serv.Handler = server.Initialize()
+
+	var err error
+	if server.Config.CORS.Enabled {
+		if err = validateCORSConfiguration(server.Config.CORS); err != nil {
+			log.Error().Err(err).Msg("Invalid CORS configuration")
+			return err
+		}
+	}
+
+	if server.Config.TLS.Enabled {
+		server.Serv.TLSConfig, err = NewTLSConfig(server.Config.TLS)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to configure TLS")
+			return err
+		}
+	}
+
+	listener, err := server.listen()
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to start HTTP server")
+		return err
+	}
+
+	if server.Config.TLS.Enabled {
+		// certificate is provided by TLS configuration
+		err = server.Serv.ServeTLS(listener, "", "")
+	} else {
+		err = server.Serv.Serve(listener)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Error().Err(err).Msg("Unable to start HTTP server")
+		return err
+	}
+
+	return nil
+}
+
+// Stop stops server's execution
+func (server *HTTPServer) Stop(ctx context.Context) error {
+	return server.Serv.Shutdown(ctx)
 }
@@ -15,3 +15,56 @@ limitations under the License.
 */
 
 package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+var config = server.Configuration{
+	Address:     ":8080",
+	APIPrefix:   "/api/test/",
+	APISpecFile: "openapi.json",
+	Debug:       true,
+	Auth:        false,
+}
+
+// makeXRHToken encodes given identity the same way as 3scale does for x-rh-identity header
+func makeXRHToken(t *testing.T, identity server.Identity) string {
+	token, err := server.NewXRHIdentityToken(identity)
+	assert.NoError(t, err)
+
+	return token
+}
+
+// executeRequest performs given request against the initialized server and returns the recorded response
+func executeRequest(testServer *server.HTTPServer, req *http.Request) *httptest.ResponseRecorder {
+	router := testServer.Initialize()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestMainEndpoint(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+
+	rr := executeRequest(server.New(config), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, rr.Body.String())
+}
+
+func TestUnknownEndpoint(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix+"unknown", nil)
+
+	rr := executeRequest(server.New(config), req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
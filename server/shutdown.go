@@ -0,0 +1,99 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// defaultShutdownTimeout is used when shutdown timeout is not configured
+const defaultShutdownTimeout = 30 * time.Second
+
+// ErrForcedShutdown is returned by Shutdown when in-flight requests have not
+// finished within shutdown timeout and their connections have been closed
+var ErrForcedShutdown = errors.New("in-flight requests have not finished in time, server closed forcibly")
+
+// Shutdown stops the server gracefully. Readiness probe starts failing first,
+// so no new requests are routed to the instance, and after the drain period
+// the server stops accepting connections and waits for in-flight requests.
+// Connections are closed forcibly when the requests do not finish within
+// shutdown timeout.
+func (server *HTTPServer) Shutdown() error {
+	server.drainOnce.Do(func() { close(server.draining) })
+
+	if server.Config.DrainPeriod > 0 {
+		log.Info().Msgf("Draining connections for %v", server.Config.DrainPeriod)
+		time.Sleep(server.Config.DrainPeriod)
+	}
+
+	timeout := server.Config.ShutdownTimeout
+	if timeout == 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Info().Msg("Shutting down HTTP server")
+
+	err := server.Stop(ctx)
+	if err == context.DeadlineExceeded {
+		log.Warn().Msgf("In-flight requests have not finished in %v, closing connections", timeout)
+		if err := server.Serv.Close(); err != nil {
+			log.Error().Err(err).Msg("Unable to close HTTP server")
+		}
+		err = ErrForcedShutdown
+	}
+
+	server.closeAccessLog()
+
+	return err
+}
+
+// Draining returns channel that is closed when the server starts shutting
+// down, background tasks can stop on it
+func (server *HTTPServer) Draining() <-chan struct{} {
+	return server.draining
+}
+
+// isDraining returns true when the server is shutting down
+func (server *HTTPServer) isDraining() bool {
+	select {
+	case <-server.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeAccessLog closes access log file, nothing is done when the access
+// log is written to standard output
+func (server *HTTPServer) closeAccessLog() {
+	file, ok := server.accessLogWriter.(*lumberjack.Logger)
+	if !ok {
+		return
+	}
+
+	if err := file.Close(); err != nil {
+		log.Error().Err(err).Msg("Unable to close access log")
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+// freeAddress returns local address with port nobody listens on
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	return listener.Addr().String()
+}
+
+// startServer starts the server in background and waits until it accepts
+// connections, errors returned by Start are sent to the returned channel
+func startServer(t *testing.T, testServer *server.HTTPServer) <-chan error {
+	// base context is requested when the server starts serving the listener
+	listening := make(chan struct{})
+	testServer.Serv.BaseContext = func(net.Listener) context.Context {
+		close(listening)
+		return context.Background()
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- testServer.Start()
+	}()
+
+	select {
+	case <-listening:
+		return serverErrors
+	case err := <-serverErrors:
+		t.Fatalf("server has not started: %v", err)
+		return nil
+	}
+}
+
+// blockingServer returns server whose rule content endpoint signals that it
+// handles the request and waits until release is closed
+func blockingServer(testConfig server.Configuration, release <-chan struct{}) (*server.HTTPServer, <-chan struct{}) {
+	handling := make(chan struct{}, 1)
+
+	testServer := server.New(testConfig)
+	testServer.RuleContent = func(ruleID, errorKey string) (interface{}, bool) {
+		handling <- struct{}{}
+		<-release
+		return nil, false
+	}
+
+	return testServer, handling
+}
+
+// requestRuleContent sends request to rule content endpoint in background,
+// the response or error is sent to the returned channel
+func requestRuleContent(address string) (<-chan int, <-chan error) {
+	responses := make(chan int, 1)
+	requestErrors := make(chan error, 1)
+
+	go func() {
+		response, err := http.Post("http://"+address+config.APIPrefix+server.RuleContentEndpoint,
+			"application/json", strings.NewReader(`[{"rule_id": "rule", "error_key": "KEY"}]`))
+		if err != nil {
+			requestErrors <- err
+			return
+		}
+		_ = response.Body.Close()
+		responses <- response.StatusCode
+	}()
+
+	return responses, requestErrors
+}
+
+func TestShutdownFailsReadinessWhileDraining(t *testing.T) {
+	testConfig := config
+	testConfig.DrainPeriod = 200 * time.Millisecond
+	testServer := server.New(testConfig)
+	testServer.RecordContentLoad(loadedContent, time.Second, nil)
+
+	shutdownErrors := make(chan error, 1)
+	go func() {
+		shutdownErrors <- testServer.Shutdown()
+	}()
+	<-testServer.Draining()
+
+	rr := executeRequest(testServer, httptest.NewRequest(http.MethodGet, server.ReadinessEndpoint, nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"shutting_down":true`)
+	assert.NoError(t, <-shutdownErrors)
+}
+
+func TestShutdownWaitsForInFlightRequests(t *testing.T) {
+	testConfig := config
+	testConfig.Address = freeAddress(t)
+
+	release := make(chan struct{})
+	testServer, handling := blockingServer(testConfig, release)
+	// the request finishes only after the server stops accepting connections
+	testServer.Serv.RegisterOnShutdown(func() { close(release) })
+	serverErrors := startServer(t, testServer)
+
+	responses, requestErrors := requestRuleContent(testConfig.Address)
+	<-handling
+
+	assert.NoError(t, testServer.Shutdown())
+	select {
+	case status := <-responses:
+		assert.Equal(t, http.StatusOK, status)
+	case err := <-requestErrors:
+		t.Fatal(err)
+	}
+	assert.NoError(t, <-serverErrors)
+}
+
+func TestShutdownForced(t *testing.T) {
+	testConfig := config
+	testConfig.Address = freeAddress(t)
+	testConfig.ShutdownTimeout = 50 * time.Millisecond
+
+	release := make(chan struct{})
+	defer close(release)
+	testServer, handling := blockingServer(testConfig, release)
+	serverErrors := startServer(t, testServer)
+
+	_, requestErrors := requestRuleContent(testConfig.Address)
+	<-handling
+
+	assert.Equal(t, server.ErrForcedShutdown, testServer.Shutdown())
+	assert.Error(t, <-requestErrors)
+	assert.NoError(t, <-serverErrors)
+}
@@ -0,0 +1,237 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// defaultReloadCheckInterval is used when the interval of checking the
+// certificate files is not configured
+const defaultReloadCheckInterval = 10 * time.Second
+
+// CertificateUsernamePrefix is prepended to the common name of the client
+// certificate to get username of the service identity, so the certificates
+// are not matched by role bindings of users
+const CertificateUsernamePrefix = "cert:"
+
+// Cipher policies of TLS configuration
+const (
+	CipherPolicyIntermediate = "intermediate"
+	CipherPolicyModern       = "modern"
+)
+
+// intermediateCipherSuites are TLS 1.2 cipher suites with forward secrecy
+// and authenticated encryption, TLS 1.3 cipher suites are not configurable
+var intermediateCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// NewTLSConfig constructs TLS configuration of the server
+func NewTLSConfig(config TLSConfiguration) (*tls.Config, error) {
+	checkInterval := config.ReloadCheckInterval
+	if checkInterval <= 0 {
+		checkInterval = defaultReloadCheckInterval
+	}
+
+	reloader, err := newCertificateReloader(config.CertFile, config.KeyFile, checkInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		GetCertificate:           reloader.getCertificate,
+		PreferServerCipherSuites: true,
+	}
+
+	switch config.MinVersion {
+	case "", "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported minimal TLS version '%s', use either 1.2 or 1.3", config.MinVersion)
+	}
+
+	switch config.CipherPolicy {
+	case "", CipherPolicyIntermediate:
+		tlsConfig.CipherSuites = intermediateCipherSuites
+	case CipherPolicyModern:
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unknown cipher policy '%s', use either %s or %s",
+			config.CipherPolicy, CipherPolicyIntermediate, CipherPolicyModern)
+	}
+
+	if config.ClientCAFile != "" {
+		// #nosec G304
+		caBundle, err := ioutil.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client CA bundle: %s", err)
+		}
+
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no certificate found in client CA bundle '%s'", config.ClientCAFile)
+		}
+
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if config.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	} else if config.RequireClientCert {
+		return nil, fmt.Errorf("client CA bundle needs to be specified to require client certificates")
+	}
+
+	return tlsConfig, nil
+}
+
+// certificateReloader provides server certificate, the certificate is
+// loaded again when its files are modified. The files are checked at most
+// once per check interval, not on every handshake.
+type certificateReloader struct {
+	certFile      string
+	keyFile       string
+	checkInterval time.Duration
+	mutex         sync.Mutex
+	certificate   *tls.Certificate
+	modTime       time.Time
+	checkedAt     time.Time
+}
+
+func newCertificateReloader(certFile, keyFile string, checkInterval time.Duration) (*certificateReloader, error) {
+	reloader := &certificateReloader{certFile: certFile, keyFile: keyFile, checkInterval: checkInterval}
+
+	modTime, err := reloader.filesModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	err = reloader.load(modTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// getCertificate returns the current certificate, it is used as
+// tls.Config.GetCertificate callback
+func (reloader *certificateReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(reloader.checkedAt) < reloader.checkInterval {
+		return reloader.certificate, nil
+	}
+	reloader.checkedAt = now
+
+	modTime, err := reloader.filesModTime()
+	if err == nil && !modTime.Equal(reloader.modTime) {
+		// the files may be in the middle of rotation, the previous
+		// certificate is used until both files are updated
+		if err := reloader.load(modTime); err != nil {
+			log.Error().Err(err).Msg("Unable to reload TLS certificate")
+		} else {
+			log.Info().Msg("TLS certificate reloaded")
+		}
+	}
+
+	return reloader.certificate, nil
+}
+
+func (reloader *certificateReloader) load(modTime time.Time) error {
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load TLS certificate: %s", err)
+	}
+
+	reloader.certificate = &certificate
+	reloader.modTime = modTime
+	return nil
+}
+
+// filesModTime returns the latest modification time of certificate and key files
+func (reloader *certificateReloader) filesModTime() (time.Time, error) {
+	var modTime time.Time
+
+	for _, file := range []string{reloader.certFile, reloader.keyFile} {
+		fileInfo, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fileInfo.ModTime().After(modTime) {
+			modTime = fileInfo.ModTime()
+		}
+	}
+
+	return modTime, nil
+}
+
+// clientCertificate returns verified client certificate of the request
+func clientCertificate(r *http.Request) (*x509.Certificate, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return r.TLS.VerifiedChains[0][0], true
+}
+
+// authenticateClientCertificate passes request with service identity named
+// after the common name of the verified client certificate. Roles are granted
+// to the identity by role bindings of the certificate only.
+func (server *HTTPServer) authenticateClientCertificate(r *http.Request, certificate *x509.Certificate) (*http.Request, bool) {
+	commonName := certificate.Subject.CommonName
+
+	identity := Identity{
+		Type:  ServiceIdentityType,
+		User:  User{Username: CertificateUsernamePrefix + commonName},
+		Roles: server.certificateRoles(commonName),
+	}
+
+	server.auditAuthentication(r, AuditReasonValidClientCertificate, identity, nil)
+
+	return withIdentity(r, identity), true
+}
+
+// certificateRoles returns roles bound to client certificates with given
+// common name
+func (server *HTTPServer) certificateRoles(commonName string) []Role {
+	var roles []Role
+
+	for _, binding := range server.Config.RoleBindings {
+		if stringInSlice(commonName, binding.Certificates) {
+			roles = append(roles, binding.Role)
+		}
+	}
+
+	return roles
+}
@@ -0,0 +1,361 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+// testCertificate is a certificate together with its private key
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certPEM     []byte
+	keyPEM      []byte
+}
+
+// newTestCertificate generates certificate signed by given CA, the
+// certificate is self-signed CA when ca is nil
+func newTestCertificate(t *testing.T, commonName string, ca *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	parent, parentKey := template, key
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		parent, parentKey = ca.certificate, ca.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCertificate) tlsCertificate(t *testing.T) tls.Certificate {
+	certificate, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+// writeFile writes file and sets its modification time
+func writeFile(t *testing.T, path string, content []byte, modTime time.Time) {
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tlsFixture contains CA and TLS configuration of the server stored in
+// temporary directory
+type tlsFixture struct {
+	dir    string
+	ca     *testCertificate
+	config server.TLSConfiguration
+}
+
+func newTLSFixture(t *testing.T) *tlsFixture {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture := &tlsFixture{
+		dir: dir,
+		ca:  newTestCertificate(t, "test-ca", nil),
+		config: server.TLSConfiguration{
+			Enabled:      true,
+			CertFile:     filepath.Join(dir, "tls.crt"),
+			KeyFile:      filepath.Join(dir, "tls.key"),
+			ClientCAFile: filepath.Join(dir, "ca.crt"),
+		},
+	}
+
+	fixture.writeServerCertificate(t, newTestCertificate(t, "content-service", fixture.ca), time.Now())
+	writeFile(t, fixture.config.ClientCAFile, fixture.ca.certPEM, time.Now())
+
+	return fixture
+}
+
+func (fixture *tlsFixture) writeServerCertificate(t *testing.T, certificate *testCertificate, modTime time.Time) {
+	writeFile(t, fixture.config.CertFile, certificate.certPEM, modTime)
+	writeFile(t, fixture.config.KeyFile, certificate.keyPEM, modTime)
+}
+
+func (fixture *tlsFixture) remove() {
+	_ = os.RemoveAll(fixture.dir)
+}
+
+// client returns HTTPS client trusting the test CA, the connections are
+// not reused so that every request makes new handshake
+func (fixture *tlsFixture) client(clientCertificates ...tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(fixture.ca.certificate)
+
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: clientCertificates,
+		},
+		DisableKeepAlives: true,
+	}}
+}
+
+// get performs GET request and returns status code of the response
+func get(client *http.Client, url string) (int, *tls.ConnectionState, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+	_ = response.Body.Close()
+
+	return response.StatusCode, response.TLS, nil
+}
+
+func startTLSServer(t *testing.T, serverConfig server.Configuration) (string, func()) {
+	serverConfig.Address = freeAddress(t)
+	testServer := server.New(serverConfig)
+	serverErrors := startServer(t, testServer)
+
+	stop := func() {
+		assert.NoError(t, testServer.Shutdown())
+		assert.NoError(t, <-serverErrors)
+	}
+
+	return "https://" + serverConfig.Address + serverConfig.APIPrefix, stop
+}
+
+func TestNewTLSConfigInvalidOptions(t *testing.T) {
+	fixture := newTLSFixture(t)
+	defer fixture.remove()
+
+	tlsConfig := fixture.config
+	tlsConfig.MinVersion = "1.0"
+	_, err := server.NewTLSConfig(tlsConfig)
+	assert.EqualError(t, err, "unsupported minimal TLS version '1.0', use either 1.2 or 1.3")
+
+	tlsConfig = fixture.config
+	tlsConfig.CipherPolicy = "old"
+	_, err = server.NewTLSConfig(tlsConfig)
+	assert.EqualError(t, err, "unknown cipher policy 'old', use either intermediate or modern")
+
+	tlsConfig = fixture.config
+	tlsConfig.ClientCAFile = ""
+	tlsConfig.RequireClientCert = true
+	_, err = server.NewTLSConfig(tlsConfig)
+	assert.Error(t, err)
+
+	tlsConfig = fixture.config
+	tlsConfig.KeyFile = filepath.Join(fixture.dir, "missing.key")
+	_, err = server.NewTLSConfig(tlsConfig)
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfigModernPolicy(t *testing.T) {
+	fixture := newTLSFixture(t)
+	defer fixture.remove()
+
+	tlsConfig := fixture.config
+	tlsConfig.CipherPolicy = server.CipherPolicyModern
+
+	serverTLSConfig, err := server.NewTLSConfig(tlsConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), serverTLSConfig.MinVersion)
+}
+
+func TestTLSServer(t *testing.T) {
+	fixture := newTLSFixture(t)
+	defer fixture.remove()
+
+	serverConfig := config
+	serverConfig.TLS = fixture.config
+	url, stop := startTLSServer(t, serverConfig)
+	defer stop()
+
+	status, _, err := get(fixture.client(), url)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestTLSServerRequiresClientCertificate(t *testing.T) {
+	fixture := newTLSFixture(t)
+	defer fixture.remove()
+
+	serverConfig := config
+	serverConfig.TLS = fixture.config
+	serverConfig.TLS.RequireClientCert = true
+	url, stop := startTLSServer(t, serverConfig)
+	defer stop()
+
+	_, _, err := get(fixture.client(), url)
+	assert.Error(t, err)
+
+	// certificate signed by unknown CA is not accepted
+	unknownCA := newTestCertificate(t, "unknown-ca", nil)
+	_, _, err = get(fixture.client(newTestCertificate(t, "smart-proxy", unknownCA).tlsCertificate(t)), url)
+	assert.Error(t, err)
+
+	clientCertificate := newTestCertificate(t, "smart-proxy", fixture.ca).tlsCertificate(t)
+	status, _, err := get(fixture.client(clientCertificate), url)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestClientCertificateIdentity(t *testing.T) {
+	fixture := newTLSFixture(t)
+	defer fixture.remove()
+
+	serverConfig := authConfig()
+	serverConfig.TLS = fixture.config
+	serverConfig.Debug = true
+	serverConfig.RoleBindings = []server.RoleBinding{
+		{Role: server.RoleOperator, Usernames: []string{"smart-proxy"}},
+	}
+	url, stop := startTLSServer(t, serverConfig)
+	defer stop()
+
+	serverConfig.RoleBindings = []server.RoleBinding{
+		{Role: server.RoleOperator, Certificates: []string{"smart-proxy"}},
+	}
+	boundURL, stopBound := startTLSServer(t, serverConfig)
+	defer stopBound()
+
+	// without client certificate the request has no identity
+	status, _, err := get(fixture.client(), url)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	clientCertificate := newTestCertificate(t, "smart-proxy", fixture.ca).tlsCertificate(t)
+
+	status, _, err = get(fixture.client(clientCertificate), url)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	// role bindings by username do not apply to the service identity
+	status, _, err = get(fixture.client(clientCertificate), url+server.DebugRoutesEndpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// role bindings by certificate do
+	status, _, err = get(fixture.client(clientCertificate), boundURL+server.DebugRoutesEndpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+	fixture := newTLSFixture(t)
+	defer fixture.remove()
+
+	serverConfig := config
+	serverConfig.TLS = fixture.config
+	serverConfig.TLS.ReloadCheckInterval = time.Nanosecond
+	url, stop := startTLSServer(t, serverConfig)
+	defer stop()
+
+	_, state, err := get(fixture.client(), url)
+	assert.NoError(t, err)
+	original := state.PeerCertificates[0].SerialNumber
+
+	rotated := newTestCertificate(t, "content-service", fixture.ca)
+	fixture.writeServerCertificate(t, rotated, time.Now().Add(time.Minute))
+
+	_, state, err = get(fixture.client(), url)
+	assert.NoError(t, err)
+	assert.NotEqual(t, original, state.PeerCertificates[0].SerialNumber)
+	assert.Equal(t, rotated.certificate.SerialNumber, state.PeerCertificates[0].SerialNumber)
+}
+
+func TestTLSCertificateReloadThrottled(t *testing.T) {
+	fixture := newTLSFixture(t)
+	defer fixture.remove()
+
+	serverConfig := config
+	serverConfig.TLS = fixture.config
+	serverConfig.TLS.ReloadCheckInterval = time.Hour
+	url, stop := startTLSServer(t, serverConfig)
+	defer stop()
+
+	_, state, err := get(fixture.client(), url)
+	assert.NoError(t, err)
+	original := state.PeerCertificates[0].SerialNumber
+
+	fixture.writeServerCertificate(t, newTestCertificate(t, "content-service", fixture.ca), time.Now().Add(time.Minute))
+
+	// the files are not checked again within the check interval
+	_, state, err = get(fixture.client(), url)
+	assert.NoError(t, err)
+	assert.Equal(t, original, state.PeerCertificates[0].SerialNumber)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// jwtClaims are claims of JWT tokens minted for local development
+type jwtClaims struct {
+	JWTPayload
+	jwt.StandardClaims
+}
+
+// NewXRHIdentityToken builds value of x-rh-identity header for given identity
+func NewXRHIdentityToken(identity Identity) (string, error) {
+	tokenBytes, err := json.Marshal(Token{Identity: identity})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(tokenBytes), nil
+}
+
+// NewJWT builds JWT token with given payload signed by given key. RSA
+// private key in PEM format is used for RS256 signature, any other key is
+// used as a secret for HS256 signature. Expiration is not set when expiresIn
+// is zero.
+//
+// The service decodes only the payload of JWT tokens, neither the signature
+// nor the expiration is verified. JWT mode is meant for local development,
+// in production the identity is verified by the gateway that sets
+// x-rh-identity header.
+func NewJWT(payload JWTPayload, expiresIn time.Duration, key []byte) (string, error) {
+	now := time.Now()
+
+	claims := jwtClaims{
+		JWTPayload: payload,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt: now.Unix(),
+		},
+	}
+	if expiresIn > 0 {
+		claims.ExpiresAt = now.Add(expiresIn).Unix()
+	}
+
+	rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(key)
+	if err == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
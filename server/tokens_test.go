@@ -0,0 +1,118 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+var testJWTPayload = server.JWTPayload{
+	Type:          "User",
+	AccountNumber: "42",
+	OrgID:         1,
+	Username:      "developer",
+}
+
+func TestNewXRHIdentityTokenAccepted(t *testing.T) {
+	token, err := server.NewXRHIdentityToken(server.Identity{
+		Type:          "User",
+		AccountNumber: "42",
+		Internal:      server.Internal{OrgID: 1},
+		User:          server.User{Username: "developer+?>"},
+	})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set("x-rh-identity", token)
+
+	rr := executeRequest(server.New(authConfig()), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestNewJWTWithSecret(t *testing.T) {
+	secret := []byte("secret")
+
+	token, err := server.NewJWT(testJWTPayload, time.Hour, secret)
+	assert.NoError(t, err)
+
+	claims := jwt.MapClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodHS256, parsed.Method)
+	assert.Equal(t, "42", claims["account_number"])
+	assert.Equal(t, "1", claims["org_id"])
+	assert.Equal(t, "developer", claims["username"])
+	assert.Contains(t, claims, "exp")
+}
+
+func TestNewJWTWithoutExpiration(t *testing.T) {
+	token, err := server.NewJWT(testJWTPayload, 0, []byte("secret"))
+	assert.NoError(t, err)
+
+	claims := jwt.MapClaims{}
+	_, _, err = new(jwt.Parser).ParseUnverified(token, claims)
+	assert.NoError(t, err)
+	assert.NotContains(t, claims, "exp")
+}
+
+func TestNewJWTWithRSAKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+
+	token, err := server.NewJWT(testJWTPayload, time.Hour, keyPEM)
+	assert.NoError(t, err)
+
+	parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+		return &privateKey.PublicKey, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodRS256, parsed.Method)
+}
+
+func TestNewJWTAccepted(t *testing.T) {
+	cfg := authConfig()
+	cfg.AuthType = "jwt"
+
+	token, err := server.NewJWT(testJWTPayload, time.Hour, []byte("secret"))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := executeRequest(server.New(cfg), req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName identifies spans created by the HTTP server
+	tracerName = "github.com/RedHatInsights/insights-content-service/server"
+
+	authenticationSpanName = "authentication"
+)
+
+// traceContext propagates W3C traceparent and tracestate headers
+var traceContext = propagation.TraceContext{}
+
+// tracer returns tracer of the global tracer provider, which is a no-op
+// provider when tracing is not configured
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Tracing middleware starts server span for each request. The span continues
+// the trace of the caller when the request contains W3C traceparent header.
+// It wraps the whole router, so requests not matching any route and CORS
+// preflight requests are traced too; the route is added to the span by
+// RecordRoute once the router matches it.
+func (server *HTTPServer) Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := traceContext.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracer().Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", "", r)...),
+			trace.WithAttributes(attribute.String("request_id", GetRequestID(r))),
+		)
+		defer span.End()
+
+		if span.SpanContext().IsValid() {
+			logger := requestLogger(r).With().
+				Str("trace_id", span.SpanContext().TraceID().String()).
+				Logger()
+			ctx = logger.WithContext(ctx)
+		}
+
+		writer := &statusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(writer, r.WithContext(ctx))
+
+		status := writer.Status()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		// client errors are not errors of the server
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// recordSpanRoute names server span of the request after the route matched
+// by the router
+func recordSpanRoute(r *http.Request) {
+	currentRoute := mux.CurrentRoute(r)
+	if currentRoute == nil {
+		return
+	}
+
+	route, err := currentRoute.GetPathTemplate()
+	if err != nil {
+		return
+	}
+
+	span := trace.SpanFromContext(r.Context())
+	span.SetName("HTTP " + r.Method + " " + route)
+	span.SetAttributes(semconv.HTTPRouteKey.String(route))
+}
+
+// startAuthenticationSpan starts span covering authentication decision made
+// for given request
+func startAuthenticationSpan(r *http.Request) (*http.Request, trace.Span) {
+	ctx, span := tracer().Start(r.Context(), authenticationSpanName)
+	return r.WithContext(ctx), span
+}
+
+// withParentSpan returns request with the span of parent request restored,
+// so that spans of the next handlers are not children of authentication span
+func withParentSpan(request, parent *http.Request) *http.Request {
+	ctx := trace.ContextWithSpan(request.Context(), trace.SpanFromContext(parent.Context()))
+	return request.WithContext(ctx)
+}
+
+// recordAuthenticationDecision adds event describing authentication
+// decision to the current span of the request
+func recordAuthenticationDecision(r *http.Request, outcome, reason, authType string) {
+	trace.SpanFromContext(r.Context()).AddEvent(auditAuthenticationEventMessage, trace.WithAttributes(
+		attribute.String("outcome", outcome),
+		attribute.String("reason", reason),
+		attribute.String("auth_type", authType),
+	))
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/RedHatInsights/insights-content-service/server"
+)
+
+const (
+	traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID = "00f067aa0ba902b7"
+)
+
+// recordSpans installs global tracer provider recording all finished spans,
+// the returned function resets it to no-op provider
+func recordSpans() (*tracetest.SpanRecorder, func()) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	return recorder, func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) }
+}
+
+// findSpan returns the finished span with given name
+func findSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+
+	t.Fatalf("span '%s' not found", name)
+	return nil
+}
+
+func hasAttribute(span sdktrace.ReadOnlySpan, attr attribute.KeyValue) bool {
+	for _, spanAttr := range span.Attributes() {
+		if spanAttr == attr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTracingRequestSpan(t *testing.T) {
+	recorder, restore := recordSpans()
+	defer restore()
+
+	executeRequest(server.New(config), httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+
+	span := findSpan(t, recorder, "HTTP GET "+config.APIPrefix)
+	assert.True(t, hasAttribute(span, attribute.Int("http.status_code", http.StatusOK)))
+	assert.True(t, hasAttribute(span, attribute.String("http.route", config.APIPrefix)))
+	assert.False(t, span.Parent().IsValid())
+}
+
+func TestTracingUnmatchedRequests(t *testing.T) {
+	recorder, restore := recordSpans()
+	defer restore()
+
+	testServer := server.New(corsConfig("https://preview.example.com"))
+
+	executeRequest(testServer, httptest.NewRequest(http.MethodGet, config.APIPrefix+"unknown", nil))
+	executeRequest(testServer, httptest.NewRequest(http.MethodPost, config.APIPrefix, nil))
+	executeRequest(testServer, preflightRequest("https://preview.example.com", http.MethodGet, ""))
+
+	for name, status := range map[string]int{
+		"HTTP GET":     http.StatusNotFound,
+		"HTTP POST":    http.StatusMethodNotAllowed,
+		"HTTP OPTIONS": http.StatusNoContent,
+	} {
+		span := findSpan(t, recorder, name)
+		assert.True(t, hasAttribute(span, attribute.Int("http.status_code", status)), name)
+	}
+}
+
+func TestTracingPropagatesTraceParent(t *testing.T) {
+	recorder, restore := recordSpans()
+	defer restore()
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+
+	executeRequest(server.New(config), req)
+
+	span := findSpan(t, recorder, "HTTP GET "+config.APIPrefix)
+	assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+	assert.Equal(t, parentSpanID, span.Parent().SpanID().String())
+	assert.True(t, span.Parent().IsRemote())
+}
+
+func TestTracingAuthenticationSpan(t *testing.T) {
+	recorder, restore := recordSpans()
+	defer restore()
+
+	req := httptest.NewRequest(http.MethodGet, config.APIPrefix, nil)
+	req.Header.Set("x-rh-identity", makeXRHToken(t, server.Identity{
+		AccountNumber: "1",
+		Internal:      server.Internal{OrgID: 1},
+	}))
+
+	rr := executeRequest(server.New(authConfig()), req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	requestSpan := findSpan(t, recorder, "HTTP GET "+config.APIPrefix)
+	authSpan := findSpan(t, recorder, "authentication")
+
+	assert.Equal(t, requestSpan.SpanContext().SpanID(), authSpan.Parent().SpanID())
+	assert.Equal(t, codes.Unset, authSpan.Status().Code)
+	if assert.Len(t, authSpan.Events(), 1) {
+		assert.Contains(t, authSpan.Events()[0].Attributes, attribute.String("reason", server.AuditReasonValidToken))
+	}
+}
+
+func TestTracingRejectedAuthentication(t *testing.T) {
+	recorder, restore := recordSpans()
+	defer restore()
+
+	rr := executeRequest(server.New(authConfig()), httptest.NewRequest(http.MethodGet, config.APIPrefix, nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	authSpan := findSpan(t, recorder, "authentication")
+	assert.Equal(t, codes.Error, authSpan.Status().Code)
+
+	requestSpan := findSpan(t, recorder, "HTTP GET "+config.APIPrefix)
+	assert.True(t, hasAttribute(requestSpan, attribute.Int("http.status_code", http.StatusForbidden)))
+	assert.Equal(t, codes.Unset, requestSpan.Status().Code)
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+// Configuration represents configuration of distributed tracing
+type Configuration struct {
+	// Exporter the spans are sent to, either "otlp" (OTLP over HTTP),
+	// "stdout" or "file", tracing is disabled when not set
+	Exporter string `mapstructure:"exporter" toml:"exporter"`
+	// Endpoint is host and port of OTLP collector, localhost:4318 is
+	// used when not set
+	Endpoint string `mapstructure:"endpoint" toml:"endpoint"`
+	// Insecure disables TLS for connection to OTLP collector
+	Insecure bool `mapstructure:"insecure" toml:"insecure"`
+	// OutputFile is the file spans are appended to by file exporter
+	OutputFile string `mapstructure:"output_file" toml:"output_file"`
+	// ServiceName is reported as service.name resource attribute,
+	// insights-content-service is used when not set
+	ServiceName string `mapstructure:"service_name" toml:"service_name"`
+	// SampleRatio is the ratio of new traces that are sampled, all traces
+	// are sampled when not set and none when set to 0. Traces started by
+	// callers are sampled according to the caller's decision.
+	SampleRatio *float64 `mapstructure:"sample_ratio" toml:"sample_ratio"`
+}
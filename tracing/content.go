@@ -0,0 +1,60 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Phases of content load traced by StartContentLoadPhase
+const (
+	ContentLoadPhaseWalk           = "walk"
+	ContentLoadPhaseParseYAML      = "parse_yaml"
+	ContentLoadPhaseRenderMarkdown = "render_markdown"
+	ContentLoadPhaseSwap           = "swap"
+)
+
+// Triggers of content load
+const (
+	ContentLoadTriggerStartup = "startup"
+	ContentLoadTriggerReload  = "reload"
+)
+
+const (
+	// tracerName identifies spans created by the content loading
+	tracerName = "github.com/RedHatInsights/insights-content-service/tracing"
+
+	contentLoadSpanName = "content load"
+)
+
+// StartContentLoad starts span covering one load of the content, trigger
+// tells whether the content is loaded on startup or reloaded
+func StartContentLoad(ctx context.Context, trigger string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, contentLoadSpanName,
+		trace.WithAttributes(attribute.String("trigger", trigger)))
+}
+
+// StartContentLoadPhase starts span of one phase of content load, it is a
+// child of the content load span started by StartContentLoad
+func StartContentLoadPhase(ctx context.Context, phase string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, contentLoadSpanName+" "+phase,
+		trace.WithAttributes(attribute.String("phase", phase)))
+}
@@ -0,0 +1,147 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing contains initialization of OpenTelemetry tracer provider
+// and W3C trace context propagation.
+//
+// HTTP requests are traced by the server package. Loads of the content are
+// traced by StartContentLoad, with one child span per load phase (walk,
+// parse YAML, render markdown, swap) started by StartContentLoadPhase. The
+// service does not load any content yet, so reloads requested by SIGHUP are
+// traced without phases.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const (
+	defaultServiceName = "insights-content-service"
+
+	// defaultSampleRatio is used when sample ratio is not configured
+	defaultSampleRatio = 1.0
+
+	// shutdownTimeout limits time spent by sending buffered spans on exit
+	shutdownTimeout = 5 * time.Second
+)
+
+// InitTracing initializes global tracer provider and propagator according to
+// configuration. The returned function flushes buffered spans and has to be
+// called before the service exits.
+func InitTracing(config Configuration) (func(), error) {
+	if config.Exporter == "" {
+		return func() {}, nil
+	}
+
+	exporter, closeOutput, err := newExporter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	sampleRatio := defaultSampleRatio
+	if config.SampleRatio != nil {
+		sampleRatio = *config.SampleRatio
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	closeTracing := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		err := provider.Shutdown(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to flush spans")
+		}
+		closeOutput()
+	}
+
+	return closeTracing, nil
+}
+
+// newExporter constructs span exporter specified in configuration together
+// with function closing its output
+func newExporter(config Configuration) (sdktrace.SpanExporter, func(), error) {
+	switch strings.ToLower(config.Exporter) {
+	case "otlp":
+		options := []otlptracehttp.Option{}
+		if config.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(config.Endpoint))
+		}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		return exporter, func() {}, err
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, func() {}, err
+	case "file":
+		if config.OutputFile == "" {
+			return nil, nil, fmt.Errorf("output file needs to be specified for file exporter")
+		}
+
+		// #nosec G302 G304
+		file, err := os.OpenFile(config.OutputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		closeFile := func() {
+			if err := file.Close(); err != nil {
+				log.Error().Err(err).Msgf("Unable to close file '%s'", file.Name())
+			}
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			closeFile()
+			return nil, nil, err
+		}
+		return exporter, closeFile, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter '%s', use otlp, stdout or file", config.Exporter)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/RedHatInsights/insights-content-service/tracing"
+)
+
+// restoreGlobals returns function resetting global tracer provider and
+// propagator changed by tracing initialization to no-op ones
+func restoreGlobals() func() {
+	return func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	}
+}
+
+func TestInitTracingDisabled(t *testing.T) {
+	defer restoreGlobals()()
+
+	closeTracing, err := tracing.InitTracing(tracing.Configuration{})
+	assert.NoError(t, err)
+	closeTracing()
+
+	_, span := otel.Tracer("test").Start(context.Background(), "span")
+	assert.False(t, span.IsRecording())
+}
+
+func TestInitTracingUnknownExporter(t *testing.T) {
+	defer restoreGlobals()()
+
+	_, err := tracing.InitTracing(tracing.Configuration{Exporter: "zipkin"})
+	assert.EqualError(t, err, "unknown trace exporter 'zipkin', use otlp, stdout or file")
+}
+
+func TestInitTracingFileExporterWithoutFile(t *testing.T) {
+	defer restoreGlobals()()
+
+	_, err := tracing.InitTracing(tracing.Configuration{Exporter: "file"})
+	assert.Error(t, err)
+}
+
+func TestInitTracingFileExporter(t *testing.T) {
+	defer restoreGlobals()()
+
+	dir, err := ioutil.TempDir("", "tracing")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	outputFile := filepath.Join(dir, "spans.json")
+
+	closeTracing, err := tracing.InitTracing(tracing.Configuration{
+		Exporter:    "file",
+		OutputFile:  outputFile,
+		ServiceName: "content-service-test",
+	})
+	assert.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	assert.True(t, span.IsRecording())
+	span.End()
+
+	// spans are flushed on close
+	closeTracing()
+
+	spans, err := ioutil.ReadFile(outputFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(spans), "test-span")
+	assert.Contains(t, string(spans), "content-service-test")
+}
+
+func TestInitTracingSetsTraceContextPropagator(t *testing.T) {
+	defer restoreGlobals()()
+
+	closeTracing, err := tracing.InitTracing(tracing.Configuration{Exporter: "otlp", Insecure: true})
+	assert.NoError(t, err)
+	defer closeTracing()
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.SpanContextFromContext(ctx).TraceID().String())
+}
+
+func TestInitTracingSampleRatio(t *testing.T) {
+	zero := 0.0
+
+	testCases := []struct {
+		sampleRatio *float64
+		recording   bool
+	}{
+		{nil, true},
+		{&zero, false},
+	}
+
+	for _, testCase := range testCases {
+		func() {
+			defer restoreGlobals()()
+
+			closeTracing, err := tracing.InitTracing(tracing.Configuration{
+				Exporter:    "stdout",
+				SampleRatio: testCase.sampleRatio,
+			})
+			assert.NoError(t, err)
+			defer closeTracing()
+
+			_, span := otel.Tracer("test").Start(context.Background(), "span")
+			assert.Equal(t, testCase.recording, span.IsRecording())
+		}()
+	}
+}
+
+func TestContentLoadSpans(t *testing.T) {
+	defer restoreGlobals()()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, loadSpan := tracing.StartContentLoad(context.Background(), tracing.ContentLoadTriggerStartup)
+	for _, phase := range []string{
+		tracing.ContentLoadPhaseWalk,
+		tracing.ContentLoadPhaseParseYAML,
+		tracing.ContentLoadPhaseRenderMarkdown,
+		tracing.ContentLoadPhaseSwap,
+	} {
+		_, phaseSpan := tracing.StartContentLoadPhase(ctx, phase)
+		phaseSpan.End()
+	}
+	loadSpan.End()
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 5) {
+		load := spans[4]
+		assert.Equal(t, "content load", load.Name())
+		assert.Contains(t, load.Attributes(), attribute.String("trigger", tracing.ContentLoadTriggerStartup))
+
+		for i, name := range []string{"walk", "parse_yaml", "render_markdown", "swap"} {
+			assert.Equal(t, "content load "+name, spans[i].Name())
+			assert.Contains(t, spans[i].Attributes(), attribute.String("phase", name))
+			assert.Equal(t, load.SpanContext().SpanID(), spans[i].Parent().SpanID())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-content-service/formats"
+	"github.com/RedHatInsights/insights-content-service/types"
+)
+
+var allFormats = []string{formats.JSON, formats.YAML, formats.Gob, formats.MessagePack}
+
+// roundTrip encodes value in given format and decodes it into decoded
+func roundTrip(t *testing.T, format string, value, decoded interface{}) {
+	var buffer bytes.Buffer
+
+	err := formats.Encode(format, &buffer, value, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = formats.Decode(format, &buffer, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, value := range []interface{}{
+		types.OrgID(4294967295),
+		types.ClusterName("c8590f31-e97e-4b85-b506-c45ce1911a12"),
+		types.Timestamp("2020-05-20T10:00:00Z"),
+		types.UserID("1234567"),
+	} {
+		for _, format := range allFormats {
+			decoded := reflect.New(reflect.TypeOf(value))
+			roundTrip(t, format, value, decoded.Interface())
+			assert.Equal(t, value, decoded.Elem().Interface(), "%T %s", value, format)
+		}
+	}
+}